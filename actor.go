@@ -0,0 +1,145 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Actor owns a machine and executes its commands, one at a time, on its own
+// goroutine. Commands are sent through a mailbox, and their results are
+// delivered on the returned channels.
+type Actor struct {
+	sm      StateMachine
+	mailbox chan message
+	stop    chan struct{}
+	done    chan struct{}
+
+	mu      sync.Mutex
+	stopped bool
+	senders sync.WaitGroup
+}
+
+type message struct {
+	ctx    context.Context
+	cmdID  CommandID
+	result chan error
+}
+
+// NewActor starts an actor executing the commands of the machine. Up to size
+// commands can be waiting in its mailbox before senders block.
+func NewActor(sm StateMachine, size int) *Actor {
+	a := &Actor{
+		sm:      sm,
+		mailbox: make(chan message, size),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	go a.loop()
+	return a
+}
+
+func (a *Actor) Do(cmdID CommandID) <-chan error {
+	return a.DoContext(context.Background(), cmdID)
+}
+
+func (a *Actor) DoWith(cmdID CommandID, payload interface{}) <-chan error {
+	return a.DoWithContext(context.Background(), cmdID, payload)
+}
+
+func (a *Actor) DoWithContext(ctx context.Context, cmdID CommandID,
+	payload interface{}) <-chan error {
+
+	return a.DoContext(context.WithValue(ctx, payloadKey{}, payload), cmdID)
+}
+
+// DoContext sends the command to the mailbox. The returned channel receives
+// the result once the command has been executed, ErrStopped when the actor
+// is stopped before the command can be sent, or the error of the context
+// when it is done before.
+func (a *Actor) DoContext(ctx context.Context, cmdID CommandID) <-chan error {
+	result := make(chan error, 1)
+
+	a.mu.Lock()
+	if a.stopped {
+		a.mu.Unlock()
+		result <- ErrStopped
+		return result
+	}
+	a.senders.Add(1)
+	a.mu.Unlock()
+	defer a.senders.Done()
+
+	select {
+	case a.mailbox <- message{ctx: ctx, cmdID: cmdID, result: result}:
+	case <-a.stop:
+		result <- ErrStopped
+	case <-ctx.Done():
+		result <- ctx.Err()
+	}
+	return result
+}
+
+// Stop stops accepting commands, and waits for the commands in the mailbox
+// to be executed or the context to be done.
+func (a *Actor) Stop(ctx context.Context) error {
+	a.mu.Lock()
+	if !a.stopped {
+		a.stopped = true
+		close(a.stop)
+	}
+	a.mu.Unlock()
+
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Done returns a channel closed once the actor is stopped and its mailbox is
+// drained.
+func (a *Actor) Done() <-chan struct{} {
+	return a.done
+}
+
+// loop executes the commands of the mailbox until the actor is stopped, and
+// then the commands sent before.
+func (a *Actor) loop() {
+	defer close(a.done)
+
+	for {
+		select {
+		case msg := <-a.mailbox:
+			msg.result <- a.execute(msg)
+		case <-a.stop:
+			a.senders.Wait()
+			for {
+				select {
+				case msg := <-a.mailbox:
+					msg.result <- a.execute(msg)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// execute executes the command, reporting panics as errors.
+func (a *Actor) execute(msg message) (err error) {
+	from := a.sm.Configuration()[0]
+	defer func() {
+		if r := recover(); r != nil {
+			cause, ok := r.(error)
+			if !ok {
+				cause = fmt.Errorf("%v", r)
+			}
+			err = newTransitionError(ErrPanicked, from, msg.cmdID, nil, cause)
+		}
+	}()
+
+	return a.sm.DoContext(msg.ctx, msg.cmdID)
+}
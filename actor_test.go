@@ -0,0 +1,161 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_Actor(t *testing.T) {
+	const (
+		draft State = iota
+		confirmed
+	)
+
+	const (
+		confirm CommandID = iota
+		count
+		crash
+	)
+
+	obj := &testObject{}
+	counted := 0
+	sm := New(obj)
+	sm.WithCommand(count, func() error {
+		counted++
+		return nil
+	}).WithCommand(crash, func() error {
+		panic("crashed")
+	})
+	sm.From(draft).
+		On(count).Internal().Add().
+		On(crash).To(confirmed).Add().
+		On(confirm).To(confirmed).Add()
+
+	actor := NewActor(sm, 10)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := <-actor.Do(count); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if counted != 100 {
+		t.Errorf("Unexpected count.\n\tExpected: %v\n\tGot: %v", 100, counted)
+	}
+
+	err := <-actor.Do(crash)
+	if !errors.Is(err, ErrPanicked) {
+		t.Errorf("Unexpected error.\n\tExpected: %v\n\tGot: %v",
+			ErrPanicked, err)
+	}
+	if obj.State() != draft {
+		t.Errorf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+			draft, obj.State())
+	}
+
+	result := actor.Do(confirm)
+	if err := actor.Stop(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := <-result; err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if obj.State() != confirmed {
+		t.Errorf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+			confirmed, obj.State())
+	}
+
+	err = <-actor.Do(count)
+	if !errors.Is(err, ErrStopped) {
+		t.Errorf("Unexpected error.\n\tExpected: %v\n\tGot: %v",
+			ErrStopped, err)
+	}
+}
+
+func Test_ActorFullMailbox(t *testing.T) {
+	const wait CommandID = 0
+
+	release := make(chan struct{})
+	sm := New(&testObject{})
+	sm.WithCommand(wait, func() error {
+		<-release
+		return nil
+	})
+	sm.From(0).On(wait).Internal().Add()
+
+	actor := NewActor(sm, 0)
+	first := actor.Do(wait)
+
+	ctx, cancel := context.WithTimeout(context.Background(),
+		10*time.Millisecond)
+	defer cancel()
+
+	// the actor is busy with the first command, so the second waits for the
+	// mailbox until the context is done
+	if err := <-actor.DoContext(ctx, wait); !errors.Is(err,
+		context.DeadlineExceeded) {
+		t.Errorf("Unexpected error.\n\tExpected: %v\n\tGot: %v",
+			context.DeadlineExceeded, err)
+	}
+
+	close(release)
+	if err := <-first; err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := actor.Stop(context.Background()); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func Test_ActorStopDeadline(t *testing.T) {
+	const wait CommandID = 0
+
+	release := make(chan struct{})
+	defer close(release)
+
+	sm := New(&testObject{})
+	sm.WithCommand(wait, func() error {
+		<-release
+		return nil
+	})
+	sm.From(0).On(wait).Internal().Add()
+
+	actor := NewActor(sm, 0)
+	actor.Do(wait)
+
+	// the actor is busy with the first command, so the second blocks on the
+	// mailbox until the actor is stopped
+	blocked := make(chan (<-chan error))
+	go func() { blocked <- actor.Do(wait) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(),
+		50*time.Millisecond)
+	defer cancel()
+
+	stopped := make(chan error)
+	go func() { stopped <- actor.Stop(ctx) }()
+
+	select {
+	case err := <-stopped:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Unexpected error.\n\tExpected: %v\n\tGot: %v",
+				context.DeadlineExceeded, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Stop did not honour its deadline")
+	}
+
+	if err := <-<-blocked; !errors.Is(err, ErrStopped) {
+		t.Errorf("Unexpected error.\n\tExpected: %v\n\tGot: %v",
+			ErrStopped, err)
+	}
+}
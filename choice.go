@@ -0,0 +1,78 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+)
+
+type ChoiceBuilder struct {
+	transition *TransitionBuilder
+	branches   []Target
+	otherwise  int
+}
+
+// When adds a branch to the choice, taken when its condition is met and no
+// previous branch was taken.
+func (c *ChoiceBuilder) When(cond Condition, s State) *ChoiceBuilder {
+	return c.WhenContext(cond.context(), s)
+}
+
+func (c *ChoiceBuilder) WhenObject(cond ObjectCondition, s State) *ChoiceBuilder {
+	return c.WhenContext(cond.context(), s)
+}
+
+func (c *ChoiceBuilder) WhenContext(cond ContextCondition,
+	s State) *ChoiceBuilder {
+
+	target := c.transition.target()
+	target.State = s
+	target.History = false
+	target.Condition = and(target.Condition, cond)
+	c.branches = append(c.branches, target)
+	return c
+}
+
+// Otherwise adds the branch taken when no other branch is taken, and adds
+// the choice to the state machine.
+func (c *ChoiceBuilder) Otherwise(s State) *TransitionBuilder {
+	t := c.transition
+	t.def.mustNotBeSealed()
+
+	c.otherwise++
+	if c.otherwise > 1 {
+		return t.next()
+	}
+
+	for _, branch := range c.branches {
+		t.add(branch)
+	}
+
+	target := t.target()
+	target.State = s
+	target.History = false
+	target.Fallback = true
+	t.add(target)
+
+	return t.next()
+}
+
+func (c *ChoiceBuilder) validate() error {
+	if c.otherwise != 1 {
+		return fmt.Errorf("choice from %s on command %v has %d "+
+			"otherwise branches", c.transition.source(), c.transition.cmdID,
+			c.otherwise)
+	}
+	return nil
+}
+
+func and(c1, c2 ContextCondition) ContextCondition {
+	if c1 == nil {
+		return c2
+	}
+	if c2 == nil {
+		return c1
+	}
+	return func(ctx context.Context, obj SMObject) bool {
+		return c1(ctx, obj) && c2(ctx, obj)
+	}
+}
@@ -0,0 +1,120 @@
+package fsm
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_Choice(t *testing.T) {
+	tests := []struct {
+		name   string
+		first  bool
+		second bool
+		to     State
+	}{
+		{
+			name:   "firstBranch",
+			first:  true,
+			second: true,
+			to:     1,
+		},
+		{
+			name:   "secondBranch",
+			first:  false,
+			second: true,
+			to:     2,
+		},
+		{
+			name:   "otherwise",
+			first:  false,
+			second: false,
+			to:     3,
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			obj := &testObject{}
+			sm := New(obj)
+			sm.From(0).
+				On(1).ToChoice().
+				When(func() bool { return test.first }, 1).
+				When(func() bool { return test.second }, 2).
+				Otherwise(3)
+
+			if err := sm.Validate(); err != nil {
+				t.Fatalf("Unexpected validation error: %s", err.Error())
+			}
+
+			if err := sm.Do(1); err != nil {
+				t.Fatalf("Unexpected error found: %s ", err.Error())
+			}
+
+			if expected, got := test.to, obj.State(); expected != got {
+				t.Errorf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+		})
+	}
+}
+
+func Test_ChoiceValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		build     func(def *Definition)
+		wantError bool
+	}{
+		{
+			name: "withoutOtherwise",
+			build: func(def *Definition) {
+				def.From(0).On(1).ToChoice().
+					When(func() bool { return true }, 1)
+			},
+			wantError: true,
+		},
+		{
+			name: "twoOtherwise",
+			build: func(def *Definition) {
+				c := def.From(0).On(1).ToChoice()
+				c.Otherwise(1)
+				c.Otherwise(2)
+			},
+			wantError: true,
+		},
+		{
+			name: "oneOtherwise",
+			build: func(def *Definition) {
+				def.From(0).On(1).ToChoice().Otherwise(1)
+			},
+			wantError: false,
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			def := NewDefinition()
+			test.build(def)
+
+			err := def.Validate()
+			if test.wantError != errors.Is(err, ErrInvalidDefinition) {
+				t.Fatalf("Unexpected validation error: %v", err)
+			}
+
+			sm := New(&testObject{})
+			test.build(sm.def)
+			err = sm.Do(1)
+			if test.wantError != errors.Is(err, ErrInvalidDefinition) {
+				t.Errorf("Unexpected Do error: %v", err)
+			}
+
+			defer func() {
+				if test.wantError != (recover() != nil) {
+					t.Errorf("Unexpected Bind behaviour")
+				}
+			}()
+			def.Bind(&testObject{})
+		})
+	}
+}
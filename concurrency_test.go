@@ -0,0 +1,145 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_DefinitionSealedAfterBuild(t *testing.T) {
+	sm := New(&testObject{})
+	if err := sm.Build(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected panic not found")
+		}
+	}()
+
+	sm.From(0).On(1).To(1).Add()
+}
+
+func Test_ConcurrentBind(t *testing.T) {
+	def := NewDefinition()
+	def.From(0).On(1).To(1).Add()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := def.Bind(&testObject{}).Do(1); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func Test_ReentrantUse(t *testing.T) {
+	obj := &testObject{}
+	sm := New(obj)
+	sm.WithCommandContext(1, func(ctx context.Context, obj SMObject) error {
+		if obj.State() != 0 {
+			return errors.New("unexpected state")
+		}
+		return sm.DoContext(ctx, 2)
+	})
+	sm.From(0).On(1).To(1).Add()
+	sm.From(1).On(2).To(2).Add()
+
+	done := make(chan error)
+	go func() { done <- sm.Do(1) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Do from an action did not complete")
+	}
+
+	if expected, got := State(2), obj.State(); expected != got {
+		t.Errorf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+			expected, got)
+	}
+}
+
+func Test_ConcurrentDo(t *testing.T) {
+	const (
+		idle State = iota
+		busy
+	)
+
+	const (
+		start CommandID = iota
+		stop
+	)
+
+	const workers = 50
+	const iterations = 100
+
+	type counter struct {
+		testObject
+		started int
+		stopped int
+	}
+
+	def := NewDefinition().
+		WithCommand(start, func(obj SMObject) error {
+			obj.(*counter).started++
+			return nil
+		}).
+		WithCommand(stop, func(obj SMObject) error {
+			obj.(*counter).stopped++
+			return nil
+		})
+	def.From(idle).On(start).To(busy).Add()
+	def.From(busy).On(stop).To(idle).Add()
+
+	def, err := def.Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	obj := &counter{}
+	sm := def.Bind(obj)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				sm.Do(start)
+				sm.Do(stop)
+				sm.CanDo(start)
+				sm.AvailableCommands()
+			}
+		}()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			other := def.Bind(&counter{})
+			for j := 0; j < iterations; j++ {
+				other.Do(start)
+				other.Do(stop)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if obj.started != obj.stopped && obj.started != obj.stopped+1 {
+		t.Errorf("Unexpected counts.\n\tStarted: %v\n\tStopped: %v",
+			obj.started, obj.stopped)
+	}
+	if obj.started == 0 {
+		t.Errorf("Unexpected count.\n\tExpected: > 0\n\tGot: %v", obj.started)
+	}
+}
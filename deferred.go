@@ -0,0 +1,84 @@
+package fsm
+
+import "context"
+
+// DeferredObject is implemented by objects persisting the commands deferred
+// by their states.
+type DeferredObject interface {
+	Deferred() []Event
+	SetDeferred([]Event)
+}
+
+// Defer declares commands deferred by the state and its substates. When a
+// deferred command cannot be executed from the active states, it is stored
+// instead of rejected, and executed once the machine enters a state where
+// it can be.
+func (d *Definition) Defer(s State, cmds ...CommandID) *Definition {
+	d.mustNotBeSealed()
+	if d.deferred[s] == nil {
+		d.deferred[s] = map[CommandID]bool{}
+	}
+	for _, cmdID := range cmds {
+		d.deferred[s][cmdID] = true
+	}
+	return d
+}
+
+func (fsm *StateMachine) Defer(s State, cmds ...CommandID) *StateMachine {
+	fsm.def.Defer(s, cmds...)
+	return fsm
+}
+
+// Deferred returns the commands stored until they can be executed, in the
+// order they were received.
+func (fsm StateMachine) Deferred() []Event {
+	fsm.rt.mu.Lock()
+	defer fsm.rt.mu.Unlock()
+	return fsm.deferred()
+}
+
+func (fsm StateMachine) deferred() []Event {
+	if obj, ok := fsm.smObject.(DeferredObject); ok {
+		return append([]Event(nil), obj.Deferred()...)
+	}
+	return append([]Event(nil), fsm.rt.deferred...)
+}
+
+func (fsm StateMachine) setDeferred(events []Event) {
+	if obj, ok := fsm.smObject.(DeferredObject); ok {
+		obj.SetDeferred(events)
+		return
+	}
+	fsm.rt.deferred = events
+}
+
+// defers reports whether any active state defers the command.
+func (d *Definition) defers(config []State, cmdID CommandID) bool {
+	for _, leaf := range config {
+		for _, s := range d.path(leaf) {
+			if d.deferred[s][cmdID] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// recall removes from the deferred commands, and returns, the first one that
+// can be executed from the active states with its conditions met.
+func (fsm StateMachine) recall(ctx context.Context) (Event, bool) {
+	deferred := fsm.deferred()
+	config := fsm.configuration()
+	for i, event := range deferred {
+		eventCtx := context.WithValue(ctx, payloadKey{}, event.Payload)
+		steps := fsm.def.steps(config, event.Command)
+		if len(resolve(eventCtx, fsm.smObject, steps)) == 0 {
+			continue
+		}
+
+		pending := append([]Event(nil), deferred[:i]...)
+		fsm.setDeferred(append(pending, deferred[i+1:]...))
+		return event, true
+	}
+	return Event{}, false
+}
@@ -0,0 +1,163 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type deferredObject struct {
+	testObject
+	deferred []Event
+}
+
+func (o *deferredObject) Deferred() []Event {
+	return o.deferred
+}
+
+func (o *deferredObject) SetDeferred(events []Event) {
+	o.deferred = events
+}
+
+func Test_DeferredCommands(t *testing.T) {
+	const (
+		draft State = iota
+		open
+		signed
+		confirmed
+	)
+
+	const (
+		confirm CommandID = iota
+		sign
+		approve
+	)
+
+	tests := []struct {
+		name         string
+		obj          SMObject
+		commands     []CommandID
+		rejected     bool
+		to           State
+		wantDeferred []Event
+	}{
+		{
+			name:         "deferred",
+			obj:          &testObject{},
+			commands:     []CommandID{sign},
+			to:           draft,
+			wantDeferred: []Event{{Command: sign, Payload: "signer"}},
+		},
+		{
+			name:         "deferred.Object",
+			obj:          &deferredObject{},
+			commands:     []CommandID{sign},
+			to:           draft,
+			wantDeferred: []Event{{Command: sign, Payload: "signer"}},
+		},
+		{
+			name:     "executedWhenHandled",
+			obj:      &testObject{},
+			commands: []CommandID{sign, confirm},
+			to:       signed,
+		},
+		{
+			name:     "executedWhenHandled.Object",
+			obj:      &deferredObject{},
+			commands: []CommandID{sign, confirm},
+			to:       signed,
+		},
+		{
+			name:         "rejectedWhenHandled",
+			obj:          &testObject{},
+			commands:     []CommandID{sign, confirm},
+			rejected:     true,
+			to:           open,
+			wantDeferred: []Event{{Command: sign, Payload: "signer"}},
+		},
+		{
+			name:         "rejectedWhenHandled.Object",
+			obj:          &deferredObject{},
+			commands:     []CommandID{sign, confirm},
+			rejected:     true,
+			to:           open,
+			wantDeferred: []Event{{Command: sign, Payload: "signer"}},
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			signer := ""
+			sm := New(test.obj)
+			sm.WithCommandContext(sign, PayloadAction(
+				func(_ context.Context, _ SMObject, s string) error {
+					signer = s
+					return nil
+				})).
+				Defer(draft, sign)
+			sm.From(draft).On(confirm).To(open).Add()
+			sm.From(open).On(sign).If(func() bool { return !test.rejected }).
+				To(signed).Add()
+			sm.From(open).On(approve).To(confirmed).Add()
+
+			for _, cmdID := range test.commands {
+				if err := sm.DoWith(cmdID, "signer"); err != nil {
+					t.Fatalf("Unexpected error found: %s ", err.Error())
+				}
+			}
+
+			if expected, got := test.to, test.obj.State(); expected != got {
+				t.Errorf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+
+			if expected, got := test.wantDeferred, sm.Deferred(); !reflect.DeepEqual(expected, got) {
+				t.Errorf("Unexpected deferred commands.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+
+			if test.wantDeferred == nil && signer != "signer" {
+				t.Errorf("Deferred command executed without its payload")
+			}
+		})
+	}
+}
+
+func Test_DeferredCommandFailing(t *testing.T) {
+	const (
+		draft State = iota
+		open
+		signed
+	)
+
+	const (
+		confirm CommandID = iota
+		sign
+	)
+
+	obj := &testObject{}
+	sm := New(obj)
+	sm.WithCommand(sign, func() error { return errors.New("sign") }).
+		Defer(draft, sign)
+	sm.From(draft).On(confirm).To(open).Add()
+	sm.From(open).On(sign).To(signed).Add()
+
+	for _, cmdID := range []CommandID{sign, confirm} {
+		if err := sm.Do(cmdID); err != nil {
+			t.Fatalf("Unexpected error found: %s ", err.Error())
+		}
+	}
+
+	if expected, got := open, obj.State(); expected != got {
+		t.Errorf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+			expected, got)
+	}
+
+	wantDeferred := []Event{{Command: sign}}
+	if got := sm.Deferred(); !reflect.DeepEqual(wantDeferred, got) {
+		t.Errorf("Unexpected deferred commands.\n\tExpected: %v\n\tGot: %v",
+			wantDeferred, got)
+	}
+}
@@ -0,0 +1,208 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+type ObjectAction func(obj SMObject) error
+type ObjectCondition func(obj SMObject) bool
+type ContextAction func(ctx context.Context, obj SMObject) error
+type ContextCondition func(ctx context.Context, obj SMObject) bool
+
+// Definition holds the commands and transitions of a state machine
+// independently of the objects it handles. Once bound to an object the
+// definition cannot be modified, and can be shared by any number of machines.
+type Definition struct {
+	commands      Commands
+	transitions   Transitions
+	entry         Hooks
+	exit          Hooks
+	parents       map[State]State
+	initial       map[State]State
+	regions       map[State][]State
+	regionOf      map[State]int
+	history       map[State]HistoryKind
+	choices       []*ChoiceBuilder
+	final         map[State]bool
+	wildcard      map[CommandID]Targets
+	complete      []ContextAction
+	maxChain      int
+	deferred      map[State]map[CommandID]bool
+	clock         Clock
+	timers        []timer
+	guardsFirst   bool
+	transactional bool
+	versioned     bool
+
+	buildMu sync.Mutex
+	sealed  uint32
+}
+
+func NewDefinition() *Definition {
+	return &Definition{
+		commands:    Commands{},
+		transitions: Transitions{},
+		entry:       Hooks{},
+		exit:        Hooks{},
+		parents:     map[State]State{},
+		initial:     map[State]State{},
+		regions:     map[State][]State{},
+		regionOf:    map[State]int{},
+		history:     map[State]HistoryKind{},
+		final:       map[State]bool{},
+		wildcard:    map[CommandID]Targets{},
+		maxChain:    defaultMaxChain,
+		deferred:    map[State]map[CommandID]bool{},
+		clock:       systemClock{},
+	}
+}
+
+func (d *Definition) WithCommand(id CommandID, action ObjectAction) *Definition {
+	return d.WithCommandContext(id, action.context())
+}
+
+func (d *Definition) WithCommandContext(id CommandID,
+	action ContextAction) *Definition {
+
+	d.mustNotBeSealed()
+	d.commands[id] = action
+	return d
+}
+
+// WithGuardsBeforeAction makes Do evaluate the transition conditions before
+// executing the command action, so the action only runs when a transition
+// can be taken.
+func (d *Definition) WithGuardsBeforeAction() *Definition {
+	d.mustNotBeSealed()
+	d.guardsFirst = true
+	return d
+}
+
+func (d *Definition) From(states ...State) *TransitionBuilder {
+	d.mustNotBeSealed()
+	t := &TransitionBuilder{
+		def:  d,
+		from: states,
+	}
+	return t
+}
+
+// FromAny starts transitions from any non final state. They are evaluated
+// after the transitions declared from the active states and their parents.
+func (d *Definition) FromAny() *TransitionBuilder {
+	d.mustNotBeSealed()
+	t := &TransitionBuilder{
+		def:      d,
+		anyState: true,
+	}
+	return t
+}
+
+// Validate reports the problems found in the definition.
+func (d *Definition) Validate() error {
+	var problems []string
+	for _, c := range d.choices {
+		if err := c.validate(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	problems = append(problems, d.validateFinal()...)
+	sort.Strings(problems)
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidDefinition,
+		strings.Join(problems, "; "))
+}
+
+func (fsm StateMachine) Validate() error {
+	return fsm.def.Validate()
+}
+
+// Build validates the definition and makes it immutable, so it can be shared
+// by machines executing commands concurrently.
+func (d *Definition) Build() (*Definition, error) {
+	if d.built() {
+		return d, nil
+	}
+
+	d.buildMu.Lock()
+	defer d.buildMu.Unlock()
+
+	if d.built() {
+		return d, nil
+	}
+
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+
+	atomic.StoreUint32(&d.sealed, 1)
+	return d, nil
+}
+
+func (d *Definition) built() bool {
+	return atomic.LoadUint32(&d.sealed) == 1
+}
+
+func (fsm StateMachine) Build() error {
+	_, err := fsm.def.Build()
+	return err
+}
+
+// Bind returns a state machine handling the object with this definition,
+// building it first. It panics if the definition is not valid.
+func (d *Definition) Bind(obj SMObject) StateMachine {
+	if _, err := d.Build(); err != nil {
+		panic(fmt.Sprintf("fsm: %v", err))
+	}
+
+	return StateMachine{
+		smObject: obj,
+		def:      d,
+		rt:       &runtime{},
+	}
+}
+
+// isKnown reports whether the command has an action or any transition.
+func (d *Definition) isKnown(cmdID CommandID) bool {
+	if _, ok := d.commands[cmdID]; ok {
+		return true
+	}
+
+	for _, cmds := range d.transitions {
+		if _, ok := cmds[cmdID]; ok {
+			return true
+		}
+	}
+
+	_, ok := d.wildcard[cmdID]
+	return ok
+}
+
+func (d *Definition) mustNotBeSealed() {
+	if d.built() {
+		panic("fsm: definition cannot be modified after being built")
+	}
+}
+
+func (a ObjectAction) context() ContextAction {
+	if a == nil {
+		return nil
+	}
+	return func(_ context.Context, obj SMObject) error { return a(obj) }
+}
+
+func (c ObjectCondition) context() ContextCondition {
+	if c == nil {
+		return nil
+	}
+	return func(_ context.Context, obj SMObject) bool { return c(obj) }
+}
@@ -0,0 +1,45 @@
+package fsm
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_DefinitionBind(t *testing.T) {
+	def := NewDefinition().
+		WithCommand(1, func(obj SMObject) error { return nil })
+
+	def.From(0).
+		On(1).IfObject(func(obj SMObject) bool {
+		return obj.(*testObject).state == 0
+	}).To(1).Add()
+
+	objects := []*testObject{{}, {}, {state: 1}}
+	wantErrors := []error{nil, nil, ErrNotAllowed}
+	for i, obj := range objects {
+		if err := def.Bind(obj).Do(1); !errors.Is(err, wantErrors[i]) {
+			t.Errorf("Unexpected error for object %d.\n\tExpected: %v"+
+				"\n\tGot: %v", i, wantErrors[i], err)
+		}
+	}
+
+	for i, expected := range []State{1, 1, 1} {
+		if got := objects[i].State(); expected != got {
+			t.Errorf("Unexpected target state for object %d.\n\tExpected: %v"+
+				"\n\tGot: %v", i, expected, got)
+		}
+	}
+}
+
+func Test_DefinitionSealedAfterBind(t *testing.T) {
+	def := NewDefinition()
+	sm := def.Bind(&testObject{})
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected panic not found")
+		}
+	}()
+
+	sm.WithCommand(1, func() error { return nil })
+}
@@ -0,0 +1,68 @@
+package fsm
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrUnknownCommand = errors.New("unknown command")
+	ErrNotAllowed     = errors.New("command not allowed from current state")
+	ErrFinalState     = errors.New("machine is in a final state")
+	ErrGuardRejected  = errors.New("no transition condition met")
+	ErrActionFailed   = errors.New("action failed")
+	ErrCancelled      = errors.New("command cancelled")
+	ErrPayloadType    = errors.New("unexpected payload type")
+	ErrChainTooLong   = errors.New("too many raised events")
+	ErrPanicked       = errors.New("action panicked")
+	ErrStopped        = errors.New("actor stopped")
+	ErrConflict       = errors.New("object changed concurrently")
+	ErrLoadPanicked   = errors.New("loader panicked")
+
+	ErrInvalidDefinition = errors.New("invalid definition")
+)
+
+// TransitionError is returned by Do when a command cannot be executed. It
+// matches, with errors.Is, the sentinel error describing the kind of failure,
+// and unwraps to the error that caused it, if any.
+type TransitionError struct {
+	From    State
+	Command CommandID
+	Targets []State
+	Kind    error
+	Err     error
+}
+
+func (e *TransitionError) Error() string {
+	msg := fmt.Sprintf("command %v from state %v: %v", e.Command, e.From,
+		e.Kind)
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	return msg
+}
+
+func (e *TransitionError) Unwrap() error {
+	return e.Err
+}
+
+func (e *TransitionError) Is(target error) bool {
+	return target == e.Kind
+}
+
+func newTransitionError(kind error, from State, cmdID CommandID,
+	targets Targets, err error) *TransitionError {
+
+	states := make([]State, 0, len(targets))
+	for _, target := range targets {
+		states = append(states, target.State)
+	}
+
+	return &TransitionError{
+		From:    from,
+		Command: cmdID,
+		Targets: states,
+		Kind:    kind,
+		Err:     err,
+	}
+}
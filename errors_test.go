@@ -0,0 +1,86 @@
+package fsm
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_TransitionErrors(t *testing.T) {
+	errAction := errors.New("action")
+
+	tests := []struct {
+		name        string
+		from        State
+		cmdID       CommandID
+		wantKind    error
+		wantCause   error
+		wantTargets int
+	}{
+		{
+			name:     "unknownCommand",
+			from:     0,
+			cmdID:    9,
+			wantKind: ErrUnknownCommand,
+		},
+		{
+			name:     "notAllowed",
+			from:     1,
+			cmdID:    1,
+			wantKind: ErrNotAllowed,
+		},
+		{
+			name:        "guardRejected",
+			from:        0,
+			cmdID:       1,
+			wantKind:    ErrGuardRejected,
+			wantTargets: 1,
+		},
+		{
+			name:        "actionFailed",
+			from:        0,
+			cmdID:       2,
+			wantKind:    ErrActionFailed,
+			wantCause:   errAction,
+			wantTargets: 1,
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			obj := &testObject{state: test.from}
+			sm := New(obj)
+			sm.WithCommand(2, func() error { return errAction })
+			sm.From(0).
+				On(1).If(func() bool { return false }).To(1).Add().
+				On(2).To(1).Add()
+
+			err := sm.Do(test.cmdID)
+
+			if !errors.Is(err, test.wantKind) {
+				t.Errorf("Unexpected error kind.\n\tExpected: %v\n\tGot: %v",
+					test.wantKind, err)
+			}
+
+			if test.wantCause != nil && !errors.Is(err, test.wantCause) {
+				t.Errorf("Unexpected error cause.\n\tExpected: %v\n\tGot: %v",
+					test.wantCause, err)
+			}
+
+			var trErr *TransitionError
+			if !errors.As(err, &trErr) {
+				t.Fatalf("Expected TransitionError, got %T", err)
+			}
+
+			if trErr.From != test.from || trErr.Command != test.cmdID {
+				t.Errorf("Unexpected error transition %v/%v", trErr.From,
+					trErr.Command)
+			}
+
+			if expected, got := test.wantTargets, len(trErr.Targets); expected != got {
+				t.Errorf("Unexpected error targets.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+		})
+	}
+}
@@ -62,6 +62,7 @@ const (
 	rejected
 	completed
 	abandoned
+	open
 )
 
 type InvoiceCommand fsm.CommandID
@@ -97,20 +98,28 @@ func NewInvoiceStateMachine(invoice *Invoice) fsm.StateMachine {
 		}(*invoice)
 	}
 
+	sm.Substates(fsm.State(open),
+		fsm.State(waitingForApproval),
+		fsm.State(waitingForsignature),
+		fsm.State(waitingForPayment))
+
+	sm.From(fsm.State(open)).
+		On(fsm.CommandID(abandon)).To(fsm.State(abandoned)).Add()
+
 	sm.From(fsm.State(waitingForApproval)).
-		On(fsm.CommandID(abandon)).To(fsm.State(abandoned)).Add().
-		On(fsm.CommandID(approve)).If(needsSignature).To(fsm.State(waitingForsignature)).Add().
-		On(fsm.CommandID(approve)).To(fsm.State(waitingForPayment)).Add().
-		On(fsm.CommandID(receiveSignature)).To(fsm.State(waitingForApproval)).Add().
+		On(fsm.CommandID(approve)).ToChoice().
+		When(needsSignature, fsm.State(waitingForsignature)).
+		Otherwise(fsm.State(waitingForPayment)).
+		On(fsm.CommandID(receiveSignature)).Internal().Add().
 		On(fsm.CommandID(reject)).To(fsm.State(rejected)).Add()
 
 	sm.From(fsm.State(waitingForsignature)).
-		On(fsm.CommandID(abandon)).To(fsm.State(abandoned)).Add().
 		On(fsm.CommandID(receiveSignature)).To(fsm.State(waitingForPayment)).Add()
 
 	sm.From(fsm.State(waitingForPayment)).
-		On(fsm.CommandID(abandon)).To(fsm.State(abandoned)).Add().
 		On(fsm.CommandID(pay)).To(fsm.State(completed)).Add()
 
+	sm.Final(fsm.State(rejected), fsm.State(completed), fsm.State(abandoned))
+
 	return sm
 }
@@ -1,6 +1,7 @@
 package invoiceFsm
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/cgxarrie-go/fsm"
@@ -815,3 +816,176 @@ func Test_AbandonCommand(t *testing.T) {
 		})
 	}
 }
+
+func Test_AvailableCommands(t *testing.T) {
+	tests := []struct {
+		name          string
+		from          InvoiceState
+		needSignature bool
+		want          []InvoiceCommand
+	}{
+		{
+			name:          "draft",
+			from:          draft,
+			needSignature: false,
+			want:          []InvoiceCommand{abandon, confirm},
+		},
+		{
+			name:          "waitingForApproval",
+			from:          waitingForApproval,
+			needSignature: true,
+			want:          []InvoiceCommand{abandon, approve, receiveSignature, reject},
+		},
+		{
+			name:          "waitingForsignature",
+			from:          waitingForsignature,
+			needSignature: true,
+			want:          []InvoiceCommand{abandon, receiveSignature},
+		},
+		{
+			name:          "waitingForPayment",
+			from:          waitingForPayment,
+			needSignature: false,
+			want:          []InvoiceCommand{abandon, pay},
+		},
+		{
+			name:          "completed",
+			from:          completed,
+			needSignature: false,
+			want:          []InvoiceCommand{},
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			inv := NewInvoice(test.needSignature)
+			inv.SetState(fsm.State(test.from))
+			sm := NewInvoiceStateMachine(&inv)
+
+			got := sm.AvailableCommands()
+			if len(got) != len(test.want) {
+				t.Fatalf("Unexpected commands.\n\tExpected: %v\n\tGot: %v",
+					test.want, got)
+			}
+
+			for i, cmd := range test.want {
+				if fsm.CommandID(cmd) != got[i] {
+					t.Fatalf("Unexpected commands.\n\tExpected: %v\n\tGot: %v",
+						test.want, got)
+				}
+			}
+		})
+	}
+}
+
+func Test_PossibleTargets(t *testing.T) {
+	tests := []struct {
+		name          string
+		needSignature bool
+		want          []InvoiceState
+	}{
+		{
+			name:          "approve.NoSignature",
+			needSignature: false,
+			want:          []InvoiceState{waitingForPayment},
+		},
+		{
+			name:          "approve.Signature",
+			needSignature: true,
+			want:          []InvoiceState{waitingForsignature, waitingForPayment},
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			inv := NewInvoice(test.needSignature)
+			inv.SetState(fsm.State(waitingForApproval))
+			sm := NewInvoiceStateMachine(&inv)
+
+			if !sm.CanDo(fsm.CommandID(approve)) {
+				t.Fatalf("Expected approve to be available")
+			}
+
+			got := sm.PossibleTargets(fsm.CommandID(approve))
+			if len(got) != len(test.want) {
+				t.Fatalf("Unexpected targets.\n\tExpected: %v\n\tGot: %v",
+					test.want, got)
+			}
+
+			for i, s := range test.want {
+				if fsm.State(s) != got[i] {
+					t.Fatalf("Unexpected targets.\n\tExpected: %v\n\tGot: %v",
+						test.want, got)
+				}
+			}
+
+			if inv.isApproved {
+				t.Errorf("Querying the machine must not execute actions")
+			}
+		})
+	}
+}
+
+func Test_FinalStates(t *testing.T) {
+	tests := []struct {
+		name      string
+		from      InvoiceState
+		wantFinal bool
+	}{
+		{
+			name:      "draft",
+			from:      draft,
+			wantFinal: false,
+		},
+		{
+			name:      "waitingForPayment",
+			from:      waitingForPayment,
+			wantFinal: false,
+		},
+		{
+			name:      "rejected",
+			from:      rejected,
+			wantFinal: true,
+		},
+		{
+			name:      "completed",
+			from:      completed,
+			wantFinal: true,
+		},
+		{
+			name:      "abandoned",
+			from:      abandoned,
+			wantFinal: true,
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			inv := NewInvoice(false)
+			inv.SetState(fsm.State(test.from))
+			sm := NewInvoiceStateMachine(&inv)
+
+			if err := sm.Validate(); err != nil {
+				t.Fatalf("Unexpected validation error: %s", err.Error())
+			}
+
+			if expected, got := test.wantFinal, sm.IsFinal(); expected != got {
+				t.Errorf("Unexpected final state.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+
+			if expected, got := test.wantFinal, sm.Done(); expected != got {
+				t.Errorf("Unexpected done machine.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+
+			err := sm.Do(fsm.CommandID(abandon))
+			if expected, got := test.wantFinal, errors.Is(err, fsm.ErrFinalState); expected != got {
+				t.Errorf("Unexpected final state error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package typedInvoiceFsm
+
+import "github.com/cgxarrie-go/fsm/typed"
+
+type Invoice struct {
+	state               InvoiceState
+	isSignatureReceived bool
+	isApproved          bool
+	needsSignature      bool
+}
+
+func (i *Invoice) SetState(state InvoiceState) {
+	i.state = state
+}
+
+func (i *Invoice) State() InvoiceState {
+	return i.state
+}
+
+func NewInvoice(needsSignature bool) Invoice {
+	return Invoice{
+		state:               draft,
+		isSignatureReceived: false,
+		isApproved:          false,
+		needsSignature:      needsSignature,
+	}
+}
+
+func (i *Invoice) Confirm() error {
+	return nil
+}
+
+func (i *Invoice) ReceiveSignature() error {
+	i.isSignatureReceived = true
+	return nil
+}
+
+func (i *Invoice) Reject() error {
+	return nil
+}
+
+func (i *Invoice) Approve() error {
+	i.isApproved = true
+	return nil
+}
+
+func (i *Invoice) Pay() error {
+	return nil
+}
+
+func (i *Invoice) Abandon() error {
+	return nil
+}
+
+func (i *Invoice) NeedsSignature() bool {
+	return i.needsSignature && !i.isSignatureReceived
+}
+
+type InvoiceState string
+
+const (
+	draft               InvoiceState = "draft"
+	waitingForApproval  InvoiceState = "waitingForApproval"
+	waitingForsignature InvoiceState = "waitingForSignature"
+	waitingForPayment   InvoiceState = "waitingForPayment"
+	rejected            InvoiceState = "rejected"
+	completed           InvoiceState = "completed"
+	abandoned           InvoiceState = "abandoned"
+)
+
+type InvoiceCommand int
+
+const (
+	abandon InvoiceCommand = iota
+	confirm
+	approve
+	receiveSignature
+	reject
+	pay
+)
+
+type InvoiceStateMachine = typed.StateMachine[InvoiceState, InvoiceCommand, *Invoice]
+
+var invoiceDefinition = newInvoiceDefinition()
+
+func newInvoiceDefinition() *typed.Definition[InvoiceState, InvoiceCommand, *Invoice] {
+
+	def := typed.NewDefinition[InvoiceState, InvoiceCommand, *Invoice]()
+	def.
+		WithCommand(abandon, (*Invoice).Abandon).
+		WithCommand(confirm, (*Invoice).Confirm).
+		WithCommand(approve, (*Invoice).Approve).
+		WithCommand(receiveSignature, (*Invoice).ReceiveSignature).
+		WithCommand(reject, (*Invoice).Reject).
+		WithCommand(pay, (*Invoice).Pay)
+
+	def.From(draft).
+		On(abandon).To(abandoned).Add().
+		On(confirm).To(waitingForApproval).Add()
+
+	def.From(waitingForApproval).
+		On(abandon).To(abandoned).Add().
+		On(approve).If((*Invoice).NeedsSignature).To(waitingForsignature).Add().
+		On(approve).Else().To(waitingForPayment).Add().
+		On(receiveSignature).To(waitingForApproval).Add().
+		On(reject).To(rejected).Add()
+
+	def.From(waitingForsignature).
+		On(abandon).To(abandoned).Add().
+		On(receiveSignature).To(waitingForPayment).Add()
+
+	def.From(waitingForPayment).
+		On(abandon).To(abandoned).Add().
+		On(pay).To(completed).Add()
+
+	return def
+}
+
+func NewInvoiceStateMachine(invoice *Invoice) InvoiceStateMachine {
+	return invoiceDefinition.Bind(invoice)
+}
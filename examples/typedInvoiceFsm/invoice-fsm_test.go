@@ -0,0 +1,88 @@
+package typedInvoiceFsm
+
+import (
+	"testing"
+)
+
+func Test_ApproveCommand(t *testing.T) {
+	tests := []struct {
+		name          string
+		from          InvoiceState
+		to            InvoiceState
+		needSignature bool
+		wantError     bool
+	}{
+		{
+			name:          "draft.NoSignature",
+			from:          draft,
+			to:            draft,
+			needSignature: false,
+			wantError:     true,
+		},
+		{
+			name:          "waitingForApproval.NoSignature",
+			from:          waitingForApproval,
+			to:            waitingForPayment,
+			needSignature: false,
+			wantError:     false,
+		},
+		{
+			name:          "waitingForApproval.Signature",
+			from:          waitingForApproval,
+			to:            waitingForsignature,
+			needSignature: true,
+			wantError:     false,
+		},
+		{
+			name:          "waitingForPayment.Signature",
+			from:          waitingForPayment,
+			to:            waitingForPayment,
+			needSignature: true,
+			wantError:     true,
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			inv := NewInvoice(test.needSignature)
+			inv.SetState(test.from)
+			sm := NewInvoiceStateMachine(&inv)
+			err := sm.Do(approve)
+			if test.wantError {
+				if err == nil {
+					t.Errorf("Expected error not found ")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("Unexpected error found: %s ", err.Error())
+				return
+			}
+
+			if expected, got := test.to, inv.State(); expected != got {
+				t.Errorf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+		})
+	}
+}
+
+func Test_AvailableCommands(t *testing.T) {
+	inv := NewInvoice(true)
+	inv.SetState(waitingForApproval)
+	sm := NewInvoiceStateMachine(&inv)
+
+	want := []InvoiceCommand{abandon, approve, receiveSignature, reject}
+	got := sm.AvailableCommands()
+	if len(got) != len(want) {
+		t.Fatalf("Unexpected commands.\n\tExpected: %v\n\tGot: %v", want, got)
+	}
+
+	for i, cmd := range want {
+		if cmd != got[i] {
+			t.Fatalf("Unexpected commands.\n\tExpected: %v\n\tGot: %v", want, got)
+		}
+	}
+}
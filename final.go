@@ -0,0 +1,78 @@
+package fsm
+
+import "fmt"
+
+// Final marks the states as final. Final states cannot have outgoing
+// transitions, and the machine is done when all its active states are final.
+func (d *Definition) Final(states ...State) *Definition {
+	d.mustNotBeSealed()
+	for _, s := range states {
+		d.final[s] = true
+	}
+	return d
+}
+
+// OnComplete adds an action executed when a command leaves the machine done.
+func (d *Definition) OnComplete(action ObjectAction) *Definition {
+	d.mustNotBeSealed()
+	d.complete = append(d.complete, action.context())
+	return d
+}
+
+func (fsm *StateMachine) Final(states ...State) *StateMachine {
+	fsm.def.Final(states...)
+	return fsm
+}
+
+func (fsm *StateMachine) OnComplete(action Action) *StateMachine {
+	fsm.def.OnComplete(action.object())
+	return fsm
+}
+
+// IsFinal reports whether the state of the object is final.
+func (fsm StateMachine) IsFinal() bool {
+	fsm.rt.mu.Lock()
+	defer fsm.rt.mu.Unlock()
+	return fsm.def.final[fsm.smObject.State()]
+}
+
+// Done reports whether all the active states of the object are final.
+func (fsm StateMachine) Done() bool {
+	fsm.rt.mu.Lock()
+	defer fsm.rt.mu.Unlock()
+	return fsm.def.done(fsm.configuration())
+}
+
+func (d *Definition) done(config []State) bool {
+	for _, s := range config {
+		if !d.final[s] {
+			return false
+		}
+	}
+	return true
+}
+
+// validateFinal reports the final states with transitions, declared on them
+// or inherited from their ancestors.
+func (d *Definition) validateFinal() []string {
+	var problems []string
+	for s := range d.final {
+		for _, from := range d.path(s) {
+			for cmdID, targets := range d.transitions[from] {
+				if len(targets) == 0 {
+					continue
+				}
+
+				if from == s {
+					problems = append(problems, fmt.Sprintf("final state %v "+
+						"has transitions on command %v", s, cmdID))
+					continue
+				}
+				problems = append(problems, fmt.Sprintf("final state %v "+
+					"inherits transitions on command %v from %v", s, cmdID,
+					from))
+			}
+		}
+	}
+	return problems
+}
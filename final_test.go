@@ -0,0 +1,102 @@
+package fsm
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_OnComplete(t *testing.T) {
+	tests := []struct {
+		name         string
+		cmdID        CommandID
+		wantComplete bool
+	}{
+		{
+			name:         "toFinalState",
+			cmdID:        1,
+			wantComplete: true,
+		},
+		{
+			name:         "toNonFinalState",
+			cmdID:        2,
+			wantComplete: false,
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			completed := false
+			obj := &testObject{}
+			sm := New(obj)
+			sm.Final(1).
+				OnComplete(func() error {
+					completed = true
+					return nil
+				})
+			sm.From(0).
+				On(1).To(1).Add().
+				On(2).To(2).Add()
+
+			if err := sm.Do(test.cmdID); err != nil {
+				t.Fatalf("Unexpected error found: %s ", err.Error())
+			}
+
+			if expected, got := test.wantComplete, completed; expected != got {
+				t.Errorf("Unexpected completion.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+
+			if expected, got := test.wantComplete, sm.Done(); expected != got {
+				t.Errorf("Unexpected done machine.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+		})
+	}
+}
+
+func Test_FinalStateValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		build     func(def *Definition)
+		wantError bool
+	}{
+		{
+			name: "declared",
+			build: func(def *Definition) {
+				def.Final(1)
+				def.From(1).On(1).To(0).Add()
+			},
+			wantError: true,
+		},
+		{
+			name: "inherited",
+			build: func(def *Definition) {
+				def.Substates(2, 0, 1).Final(1)
+				def.From(2).On(1).To(3).Add()
+			},
+			wantError: true,
+		},
+		{
+			name: "none",
+			build: func(def *Definition) {
+				def.Substates(2, 0, 1).Final(3)
+				def.From(2).On(1).To(3).Add()
+			},
+			wantError: false,
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			def := NewDefinition()
+			test.build(def)
+
+			err := def.Validate()
+			if test.wantError != errors.Is(err, ErrInvalidDefinition) {
+				t.Errorf("Unexpected validation error: %v", err)
+			}
+		})
+	}
+}
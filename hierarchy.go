@@ -0,0 +1,194 @@
+package fsm
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Substates declares the states as children of the parent state. Transitions
+// declared from the parent are inherited by its children, and are evaluated
+// after the ones declared from the children themselves.
+func (d *Definition) Substates(parent State, children ...State) *Definition {
+	d.mustNotBeSealed()
+	for _, child := range children {
+		if p, ok := d.parents[child]; ok && p != parent {
+			panic(fmt.Sprintf("fsm: state %v is already a substate of %v",
+				child, p))
+		}
+
+		for _, s := range d.path(parent) {
+			if s == child {
+				panic(fmt.Sprintf("fsm: state %v cannot be a substate of %v",
+					child, parent))
+			}
+		}
+
+		d.parents[child] = parent
+	}
+	return d
+}
+
+// Initial sets the substate entered when a transition targets the parent
+// state.
+func (d *Definition) Initial(parent State, child State) *Definition {
+	d.mustNotBeSealed()
+	d.initial[parent] = child
+	return d
+}
+
+func (fsm *StateMachine) Substates(parent State, children ...State) *StateMachine {
+	fsm.def.Substates(parent, children...)
+	return fsm
+}
+
+func (fsm *StateMachine) Initial(parent State, child State) *StateMachine {
+	fsm.def.Initial(parent, child)
+	return fsm
+}
+
+// path returns the state followed by all its ancestors, innermost first.
+func (d *Definition) path(s State) []State {
+	path := []State{s}
+	for {
+		parent, ok := d.parents[s]
+		if !ok {
+			return path
+		}
+		path = append(path, parent)
+		s = parent
+	}
+}
+
+// transition returns the states left, innermost first, and entered,
+// outermost first, when taking a transition from an active state of the
+// configuration to the target, together with the resulting configuration.
+// Transitions are external, so a state containing both the source and the
+// target is left and entered again. When substates of the target are
+// remembered, they are entered instead of the initial ones.
+func (d *Definition) transition(config []State, from, to State,
+	remembered []State) (exited, entered, next []State) {
+
+	fromPath := d.path(from)
+	toPath := d.path(to)
+	depth := commonDepth(fromPath, toPath)
+
+	// the innermost state remaining active, if any, and the path below it
+	var domain State
+	below := toPath
+	if depth > 0 {
+		domain = toPath[len(toPath)-depth]
+		below = toPath[:len(toPath)-depth]
+	}
+
+	affected := func(leaf State) bool {
+		if depth == 0 {
+			return true
+		}
+
+		path := d.path(leaf)
+		for i := 1; i < len(path); i++ {
+			if path[i] != domain {
+				continue
+			}
+			if len(d.regions[domain]) == 0 {
+				return true
+			}
+			return d.regionOf[path[i-1]] == d.regionOf[below[len(below)-1]]
+		}
+		return false
+	}
+
+	left := map[State]bool{}
+	var leaves []State
+	for _, leaf := range config {
+		if !affected(leaf) {
+			continue
+		}
+		for _, s := range d.path(leaf) {
+			if depth > 0 && s == domain {
+				break
+			}
+			if !left[s] {
+				left[s] = true
+				exited = append(exited, s)
+			}
+		}
+	}
+	sort.SliceStable(exited, func(i, j int) bool {
+		return len(d.path(exited[i])) > len(d.path(exited[j]))
+	})
+
+	for i := len(below) - 1; i >= 0; i-- {
+		s := below[i]
+		entered = append(entered, s)
+		if i == 0 {
+			break
+		}
+		for region, initial := range d.regions[s] {
+			if region == d.regionOf[below[i-1]] {
+				continue
+			}
+			e, l := d.drill(initial)
+			entered = append(entered, e...)
+			leaves = append(leaves, l...)
+		}
+	}
+	e, l := d.drill(to)
+	if len(remembered) > 0 {
+		e, l = d.restore(to, remembered)
+	}
+	entered = append(entered, e[1:]...)
+	leaves = append(leaves, l...)
+
+	inserted := false
+	for _, leaf := range config {
+		if !affected(leaf) {
+			next = append(next, leaf)
+			continue
+		}
+		if !inserted {
+			next = append(next, leaves...)
+			inserted = true
+		}
+	}
+
+	return exited, entered, next
+}
+
+// drill returns the state and the substates entered with it, following the
+// initial state of every region and substate, and the resulting active
+// states.
+func (d *Definition) drill(s State) (entered []State, leaves []State) {
+	entered = []State{s}
+
+	if initials := d.regions[s]; len(initials) > 0 {
+		for _, initial := range initials {
+			e, l := d.drill(initial)
+			entered = append(entered, e...)
+			leaves = append(leaves, l...)
+		}
+		return entered, leaves
+	}
+
+	if child, ok := d.initial[s]; ok {
+		e, l := d.drill(child)
+		return append(entered, e...), l
+	}
+
+	return entered, []State{s}
+}
+
+// commonDepth returns the number of common outermost ancestors of both paths
+// that are not left by a transition between them.
+func commonDepth(fromPath, toPath []State) int {
+	depth := 0
+	for depth < len(fromPath) && depth < len(toPath) &&
+		fromPath[len(fromPath)-1-depth] == toPath[len(toPath)-1-depth] {
+		depth++
+	}
+
+	if depth == len(fromPath) || depth == len(toPath) {
+		depth--
+	}
+	return depth
+}
@@ -0,0 +1,97 @@
+package fsm
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_HierarchicalStates(t *testing.T) {
+	const (
+		idle State = iota
+		open
+		approving
+		paying
+		closed
+	)
+
+	tests := []struct {
+		name      string
+		from      State
+		cmdID     CommandID
+		to        State
+		wantCalls string
+	}{
+		{
+			name:      "inheritedFromParent",
+			from:      approving,
+			cmdID:     9,
+			to:        closed,
+			wantCalls: "exitApproving,exitOpen,enterClosed",
+		},
+		{
+			name:      "overriddenByChild",
+			from:      paying,
+			cmdID:     9,
+			to:        idle,
+			wantCalls: "exitPaying,exitOpen,enterIdle",
+		},
+		{
+			name:      "betweenSiblings",
+			from:      approving,
+			cmdID:     1,
+			to:        paying,
+			wantCalls: "exitApproving,enterPaying",
+		},
+		{
+			name:      "toParentInitialState",
+			from:      idle,
+			cmdID:     2,
+			to:        approving,
+			wantCalls: "exitIdle,enterOpen,enterApproving",
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			calls := []string{}
+			record := func(call string) Action {
+				return func() error {
+					calls = append(calls, call)
+					return nil
+				}
+			}
+
+			obj := &testObject{state: test.from}
+			sm := New(obj)
+			sm.Substates(open, approving, paying).
+				Initial(open, approving)
+
+			names := map[State]string{idle: "Idle", open: "Open",
+				approving: "Approving", paying: "Paying", closed: "Closed"}
+			for s, name := range names {
+				sm.OnEnter(s, record("enter"+name)).
+					OnExit(s, record("exit"+name))
+			}
+
+			sm.From(idle).On(2).To(open).Add()
+			sm.From(open).On(9).To(closed).Add()
+			sm.From(approving).On(1).To(paying).Add()
+			sm.From(paying).On(9).To(idle).Add()
+
+			if err := sm.Do(test.cmdID); err != nil {
+				t.Fatalf("Unexpected error found: %s ", err.Error())
+			}
+
+			if expected, got := test.to, obj.State(); expected != got {
+				t.Errorf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+
+			if expected, got := test.wantCalls, strings.Join(calls, ","); expected != got {
+				t.Errorf("Unexpected calls.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+		})
+	}
+}
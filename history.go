@@ -0,0 +1,130 @@
+package fsm
+
+type HistoryKind int
+
+const (
+	// ShallowHistory remembers the direct substates last active.
+	ShallowHistory HistoryKind = iota
+	// DeepHistory remembers the innermost states last active.
+	DeepHistory
+)
+
+// HistoryObject is implemented by objects persisting, for every state with
+// history, the substates active when the state was last left. The substates
+// of other objects are only remembered by the machine.
+type HistoryObject interface {
+	History() map[State][]State
+	SetHistory(map[State][]State)
+}
+
+// History declares the history pseudo-state of the parent state, targeted
+// with ToHistory. A state without remembered substates is entered as usual.
+func (d *Definition) History(parent State, kind HistoryKind) *Definition {
+	d.mustNotBeSealed()
+	d.history[parent] = kind
+	return d
+}
+
+func (fsm *StateMachine) History(parent State, kind HistoryKind) *StateMachine {
+	fsm.def.History(parent, kind)
+	return fsm
+}
+
+// remembered returns the substates remembered for the state.
+func (fsm StateMachine) remembered(s State) []State {
+	return fsm.histories()[s]
+}
+
+func (fsm StateMachine) histories() map[State][]State {
+	if obj, ok := fsm.smObject.(HistoryObject); ok {
+		return obj.History()
+	}
+	return fsm.rt.history
+}
+
+func (fsm StateMachine) setHistories(history map[State][]State) {
+	if obj, ok := fsm.smObject.(HistoryObject); ok {
+		obj.SetHistory(history)
+		return
+	}
+	fsm.rt.history = history
+}
+
+// remember stores, for the states with history being left, their active
+// substates.
+func (fsm StateMachine) remember(config []State, left []State) {
+	var history map[State][]State
+	for _, s := range left {
+		kind, ok := fsm.def.history[s]
+		if !ok {
+			continue
+		}
+
+		states := fsm.def.active(config, s, kind)
+		if len(states) == 0 {
+			continue
+		}
+
+		if history == nil {
+			history = map[State][]State{}
+			for k, v := range fsm.histories() {
+				history[k] = v
+			}
+		}
+		history[s] = states
+	}
+
+	if history != nil {
+		fsm.setHistories(history)
+	}
+}
+
+// active returns the substates of the state active in the configuration.
+func (d *Definition) active(config []State, s State,
+	kind HistoryKind) []State {
+
+	var states []State
+	for _, leaf := range config {
+		path := d.path(leaf)
+		for i := 1; i < len(path); i++ {
+			if path[i] != s {
+				continue
+			}
+
+			state := path[i-1]
+			if kind == DeepHistory {
+				state = leaf
+			}
+			if !contains(states, state) {
+				states = append(states, state)
+			}
+		}
+	}
+	return states
+}
+
+// restore returns the state and the substates entered with it, following
+// the remembered substates, and the resulting active states.
+func (d *Definition) restore(s State, remembered []State) (entered []State,
+	leaves []State) {
+
+	entered = []State{s}
+	for _, state := range remembered {
+		path := d.path(state)
+		for i := len(path) - 1; i >= 0; i-- {
+			if len(d.path(path[i])) <= len(d.path(s)) ||
+				contains(entered, path[i]) {
+				continue
+			}
+			if i > 0 {
+				entered = append(entered, path[i])
+				continue
+			}
+
+			e, l := d.drill(path[i])
+			entered = append(entered, e...)
+			leaves = append(leaves, l...)
+		}
+	}
+	return entered, leaves
+}
@@ -0,0 +1,135 @@
+package fsm
+
+import (
+	"strings"
+	"testing"
+)
+
+type historyObject struct {
+	testObject
+	history map[State][]State
+}
+
+func (o *historyObject) History() map[State][]State {
+	return o.history
+}
+
+func (o *historyObject) SetHistory(history map[State][]State) {
+	o.history = history
+}
+
+func Test_History(t *testing.T) {
+	const (
+		open State = iota
+		approving
+		paying
+		payingByCard
+		payingByTransfer
+		suspended
+	)
+
+	const (
+		next CommandID = iota
+		transfer
+		suspend
+		resume
+	)
+
+	tests := []struct {
+		name      string
+		kind      HistoryKind
+		noHistory bool
+		from      State
+		commands  []CommandID
+		to        State
+		wantCalls string
+	}{
+		{
+			name:      "shallow",
+			kind:      ShallowHistory,
+			from:      approving,
+			commands:  []CommandID{next, transfer, suspend, resume},
+			to:        payingByCard,
+			wantCalls: "enterOpen,enterPaying,enterPayingByCard",
+		},
+		{
+			name:      "deep",
+			kind:      DeepHistory,
+			from:      approving,
+			commands:  []CommandID{next, transfer, suspend, resume},
+			to:        payingByTransfer,
+			wantCalls: "enterOpen,enterPaying,enterPayingByTransfer",
+		},
+		{
+			name:      "notRemembered",
+			kind:      DeepHistory,
+			from:      suspended,
+			commands:  []CommandID{resume},
+			to:        approving,
+			wantCalls: "enterOpen,enterApproving",
+		},
+		{
+			name:      "objectWithoutHistory",
+			kind:      DeepHistory,
+			noHistory: true,
+			from:      approving,
+			commands:  []CommandID{next, transfer, suspend, resume},
+			to:        payingByTransfer,
+			wantCalls: "enterOpen,enterPaying,enterPayingByTransfer",
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			calls := []string{}
+			record := func(call string) Action {
+				return func() error {
+					calls = append(calls, call)
+					return nil
+				}
+			}
+
+			var obj SMObject = &historyObject{testObject: testObject{state: test.from}}
+			if test.noHistory {
+				obj = &testObject{state: test.from}
+			}
+
+			sm := New(obj)
+			sm.Substates(open, approving, paying).
+				Substates(paying, payingByCard, payingByTransfer).
+				Initial(open, approving).
+				Initial(paying, payingByCard).
+				History(open, test.kind)
+
+			names := map[State]string{open: "Open", approving: "Approving",
+				paying: "Paying", payingByCard: "PayingByCard",
+				payingByTransfer: "PayingByTransfer"}
+			for s, name := range names {
+				sm.OnEnter(s, record("enter"+name))
+			}
+
+			sm.From(approving).On(next).To(paying).Add()
+			sm.From(payingByCard).On(transfer).To(payingByTransfer).Add()
+			sm.From(open).On(suspend).To(suspended).Add()
+			sm.From(suspended).On(resume).ToHistory(open).Add()
+
+			for _, cmdID := range test.commands {
+				calls = calls[:0]
+				if err := sm.Do(cmdID); err != nil {
+					t.Fatalf("Unexpected error found: %s ", err.Error())
+				}
+			}
+
+			if expected, got := test.to, obj.State(); expected != got {
+				t.Errorf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+
+			if expected, got := test.wantCalls, strings.Join(calls, ","); expected != got {
+				t.Errorf("Unexpected calls.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+		})
+	}
+}
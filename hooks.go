@@ -0,0 +1,66 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+)
+
+type Hooks map[State][]ContextAction
+
+// OnEnter adds an action executed every time the object enters the state,
+// after its state has been changed.
+func (d *Definition) OnEnter(s State, action ObjectAction) *Definition {
+	d.mustNotBeSealed()
+	d.entry[s] = append(d.entry[s], action.context())
+	return d
+}
+
+// OnExit adds an action executed every time the object leaves the state,
+// once a transition whose conditions are met is found: after the command
+// action, or before it when guards are evaluated first. An error returned by
+// the action aborts the transition.
+func (d *Definition) OnExit(s State, action ObjectAction) *Definition {
+	d.mustNotBeSealed()
+	d.exit[s] = append(d.exit[s], action.context())
+	return d
+}
+
+func (fsm *StateMachine) OnEnter(s State, action Action) *StateMachine {
+	fsm.def.OnEnter(s, action.object())
+	return fsm
+}
+
+func (fsm *StateMachine) OnExit(s State, action Action) *StateMachine {
+	fsm.def.OnExit(s, action.object())
+	return fsm
+}
+
+func (h Hooks) run(ctx context.Context, s State, obj SMObject) error {
+	for _, action := range h[s] {
+		if action == nil {
+			continue
+		}
+		if err := action(ctx, obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fsm StateMachine) exit(ctx context.Context, states []State) error {
+	for _, s := range states {
+		if err := fsm.def.exit.run(ctx, s, fsm.smObject); err != nil {
+			return fmt.Errorf("exit from state %v: %w", s, err)
+		}
+	}
+	return nil
+}
+
+func (fsm StateMachine) enter(ctx context.Context, states []State) error {
+	for _, s := range states {
+		if err := fsm.def.entry.run(ctx, s, fsm.smObject); err != nil {
+			return fmt.Errorf("entry to state %v: %w", s, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package fsm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func Test_EntryAndExitHooks(t *testing.T) {
+	tests := []struct {
+		name          string
+		exitErr       error
+		enterErr      error
+		guardRejected bool
+		guardsFirst   bool
+		wantCalls     string
+		wantError     bool
+		to            State
+	}{
+		{
+			name:      "ok",
+			wantCalls: "action,exit0,enter1",
+			to:        1,
+		},
+		{
+			name:      "exitError",
+			exitErr:   errors.New("exit"),
+			wantCalls: "action,exit0",
+			wantError: true,
+			to:        0,
+		},
+		{
+			name:      "enterError",
+			enterErr:  errors.New("enter"),
+			wantCalls: "action,exit0,enter1",
+			wantError: true,
+			to:        1,
+		},
+		{
+			name:          "guardRejected",
+			guardRejected: true,
+			wantCalls:     "action",
+			wantError:     true,
+			to:            0,
+		},
+		{
+			name:        "guardsFirst",
+			guardsFirst: true,
+			wantCalls:   "exit0,action,enter1",
+			to:          1,
+		},
+		{
+			name:        "guardsFirstExitError",
+			guardsFirst: true,
+			exitErr:     errors.New("exit"),
+			wantCalls:   "exit0",
+			wantError:   true,
+			to:          0,
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			calls := []string{}
+			record := func(call string, err error) Action {
+				return func() error {
+					calls = append(calls, call)
+					return err
+				}
+			}
+
+			obj := &testObject{}
+			sm := New(obj)
+			sm.WithCommand(1, record("action", nil)).
+				OnExit(0, record("exit0", test.exitErr)).
+				OnEnter(0, record("enter0", nil)).
+				OnExit(1, record("exit1", nil)).
+				OnEnter(1, record("enter1", test.enterErr))
+			sm.From(0).On(1).If(func() bool { return !test.guardRejected }).
+				To(1).Add()
+			if test.guardsFirst {
+				sm.WithGuardsBeforeAction()
+			}
+
+			err := sm.Do(1)
+			if test.wantError != (err != nil) {
+				t.Errorf("Unexpected error: %v", err)
+			}
+
+			if expected, got := test.wantCalls, strings.Join(calls, ","); expected != got {
+				t.Errorf("Unexpected calls.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+
+			if expected, got := test.to, obj.State(); expected != got {
+				t.Errorf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+		})
+	}
+}
+
+func Test_SelfTransitions(t *testing.T) {
+	tests := []struct {
+		name        string
+		guardsFirst bool
+		cmdID       CommandID
+		wantCalls   string
+		wantSet     int
+	}{
+		{
+			name:      "external",
+			cmdID:     1,
+			wantCalls: "action,exit0,then,enter0",
+			wantSet:   1,
+		},
+		{
+			name:      "internal",
+			cmdID:     2,
+			wantCalls: "action,then",
+			wantSet:   0,
+		},
+		{
+			name:        "internal.GuardsFirst",
+			guardsFirst: true,
+			cmdID:       2,
+			wantCalls:   "action,then",
+			wantSet:     0,
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			calls := []string{}
+			record := func(call string) Action {
+				return func() error {
+					calls = append(calls, call)
+					return nil
+				}
+			}
+
+			obj := &countingObject{}
+			sm := New(obj)
+			if test.guardsFirst {
+				sm.WithGuardsBeforeAction()
+			}
+			sm.WithCommand(1, record("action")).
+				WithCommand(2, record("action")).
+				OnExit(0, record("exit0")).
+				OnEnter(0, record("enter0"))
+			sm.From(0).
+				On(1).Then(record("then")).To(0).Add().
+				On(2).Then(record("then")).Internal().Add()
+
+			if err := sm.Do(test.cmdID); err != nil {
+				t.Fatalf("Unexpected error found: %s ", err.Error())
+			}
+
+			if expected, got := test.wantCalls, strings.Join(calls, ","); expected != got {
+				t.Errorf("Unexpected calls.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+
+			if expected, got := test.wantSet, obj.sets; expected != got {
+				t.Errorf("Unexpected SetState calls.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+		})
+	}
+}
+
+type countingObject struct {
+	testObject
+	sets int
+}
+
+func (o *countingObject) SetState(s State) {
+	o.sets++
+	o.testObject.SetState(s)
+}
@@ -0,0 +1,250 @@
+package fsm
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Loader returns the object with the ID.
+type Loader[K comparable] func(ctx context.Context, id K) (SMObject, error)
+
+// Manager holds the machines of the objects handled with a definition, keyed
+// by their ID. Objects are loaded the first time they are used, and there is
+// a single machine per ID, so the commands for an ID are serialised. When
+// there are more machines than its capacity, the least recently used ones
+// not executing any command are evicted.
+type Manager[K comparable] struct {
+	def      *Definition
+	load     Loader[K]
+	capacity int
+
+	mu        sync.Mutex
+	instances map[K]*list.Element
+	lru       *list.List
+}
+
+type instance[K comparable] struct {
+	id     K
+	sm     StateMachine
+	err    error
+	loaded chan struct{}
+	users  int
+}
+
+// NewManager returns a manager loading the objects with the loader, and
+// keeping up to capacity idle machines. A capacity of 0 keeps all of them. It
+// panics if the definition is not valid.
+func NewManager[K comparable](def *Definition, load Loader[K],
+	capacity int) *Manager[K] {
+
+	if _, err := def.Build(); err != nil {
+		panic(fmt.Sprintf("fsm: %v", err))
+	}
+
+	return &Manager[K]{
+		def:       def,
+		load:      load,
+		capacity:  capacity,
+		instances: map[K]*list.Element{},
+		lru:       list.New(),
+	}
+}
+
+func (m *Manager[K]) Do(id K, cmdID CommandID) error {
+	return m.DoContext(context.Background(), id, cmdID)
+}
+
+func (m *Manager[K]) DoWith(id K, cmdID CommandID, payload interface{}) error {
+	return m.DoWithContext(context.Background(), id, cmdID, payload)
+}
+
+func (m *Manager[K]) DoWithContext(ctx context.Context, id K, cmdID CommandID,
+	payload interface{}) error {
+
+	return m.DoContext(context.WithValue(ctx, payloadKey{}, payload), id, cmdID)
+}
+
+// DoContext executes the command on the machine of the object with the ID,
+// loading it if needed.
+func (m *Manager[K]) DoContext(ctx context.Context, id K,
+	cmdID CommandID) error {
+
+	sm, err := m.acquire(ctx, id)
+	if err != nil {
+		return err
+	}
+	defer m.release(id)
+
+	return sm.DoContext(ctx, cmdID)
+}
+
+// Handle gives access to a machine held by a manager, which is not evicted
+// until the handle is released.
+type Handle struct {
+	StateMachine
+	release func()
+	once    sync.Once
+}
+
+// Release allows the machine to be evicted. The handle must not be used
+// afterwards.
+func (h *Handle) Release() {
+	h.once.Do(h.release)
+}
+
+// Get returns a handle to the machine of the object with the ID, loading it
+// if needed. The handle must be released once it is no longer used.
+func (m *Manager[K]) Get(ctx context.Context, id K) (*Handle, error) {
+	sm, err := m.acquire(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &Handle{StateMachine: sm, release: func() { m.release(id) }}, nil
+}
+
+// Evict removes the machine of the object with the ID, so the object is
+// loaded again the next time it is used. It reports whether the machine was
+// removed, which it is not while executing a command.
+func (m *Manager[K]) Evict(id K) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.instances[id]
+	if !ok || e.Value.(*instance[K]).users > 0 {
+		return false
+	}
+
+	m.remove(e)
+	return true
+}
+
+// Len returns the number of machines held.
+func (m *Manager[K]) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.instances)
+}
+
+// Counts returns the number of machines held in each of the active states.
+func (m *Manager[K]) Counts() map[State]int {
+	m.mu.Lock()
+	var machines []StateMachine
+	for e := m.lru.Front(); e != nil; e = e.Next() {
+		inst := e.Value.(*instance[K])
+		select {
+		case <-inst.loaded:
+			if inst.err == nil {
+				machines = append(machines, inst.sm)
+			}
+		default:
+		}
+	}
+	m.mu.Unlock()
+
+	counts := map[State]int{}
+	for _, sm := range machines {
+		for _, s := range sm.Configuration() {
+			counts[s]++
+		}
+	}
+	return counts
+}
+
+// acquire returns the machine of the object with the ID, which cannot be
+// evicted until it is released.
+func (m *Manager[K]) acquire(ctx context.Context, id K) (StateMachine, error) {
+	m.mu.Lock()
+	e, ok := m.instances[id]
+	if ok {
+		m.lru.MoveToFront(e)
+	} else {
+		e = m.lru.PushFront(&instance[K]{id: id, loaded: make(chan struct{})})
+		m.instances[id] = e
+	}
+
+	inst := e.Value.(*instance[K])
+	inst.users++
+	m.mu.Unlock()
+
+	if !ok {
+		m.init(ctx, inst)
+	}
+
+	select {
+	case <-inst.loaded:
+	case <-ctx.Done():
+		m.release(id)
+		return StateMachine{}, ctx.Err()
+	}
+
+	if inst.err != nil {
+		m.release(id)
+		return StateMachine{}, inst.err
+	}
+	return inst.sm, nil
+}
+
+// init loads the object of the instance and binds its machine. A panicking
+// loader fails the instance with ErrLoadPanicked, so it is evicted once
+// released.
+func (m *Manager[K]) init(ctx context.Context, inst *instance[K]) {
+	defer close(inst.loaded)
+	defer func() {
+		if r := recover(); r != nil {
+			inst.err = fmt.Errorf("%w: %v", ErrLoadPanicked, r)
+		}
+	}()
+
+	obj, err := m.load(ctx, inst.id)
+	if err == nil {
+		inst.sm = m.def.Bind(obj)
+	}
+	inst.err = err
+}
+
+// release marks the machine of the object with the ID as no longer used, and
+// evicts the machines beyond the capacity.
+func (m *Manager[K]) release(id K) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.instances[id]
+	if !ok {
+		return
+	}
+
+	inst := e.Value.(*instance[K])
+	inst.users--
+
+	select {
+	case <-inst.loaded:
+		if inst.err != nil && inst.users == 0 {
+			m.remove(e)
+		}
+	default:
+	}
+
+	m.evict()
+}
+
+// evict removes the least recently used idle machines beyond the capacity.
+func (m *Manager[K]) evict() {
+	if m.capacity <= 0 {
+		return
+	}
+
+	for e := m.lru.Back(); e != nil && len(m.instances) > m.capacity; {
+		prev := e.Prev()
+		if e.Value.(*instance[K]).users == 0 {
+			m.remove(e)
+		}
+		e = prev
+	}
+}
+
+func (m *Manager[K]) remove(e *list.Element) {
+	m.lru.Remove(e)
+	delete(m.instances, e.Value.(*instance[K]).id)
+}
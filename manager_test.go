@@ -0,0 +1,209 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_Manager(t *testing.T) {
+	const (
+		draft State = iota
+		confirmed
+	)
+
+	const confirm CommandID = 0
+
+	errNotFound := errors.New("not found")
+
+	def := NewDefinition()
+	def.From(draft).On(confirm).To(confirmed).Add()
+
+	var mu sync.Mutex
+	loads := map[int]int{}
+	objects := map[int]*testObject{1: {}, 2: {}, 3: {}}
+	m := NewManager(def, func(ctx context.Context, id int) (SMObject, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		loads[id]++
+		obj, ok := objects[id]
+		if !ok {
+			return nil, errNotFound
+		}
+		return obj, nil
+	}, 2)
+
+	if err := m.Do(1, confirm); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := m.Do(1, confirm); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("Unexpected error.\n\tExpected: %v\n\tGot: %v",
+			ErrNotAllowed, err)
+	}
+	if err := m.Do(4, confirm); !errors.Is(err, errNotFound) {
+		t.Errorf("Unexpected error.\n\tExpected: %v\n\tGot: %v",
+			errNotFound, err)
+	}
+
+	for _, id := range []int{2, 3} {
+		h, err := m.Get(context.Background(), id)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		h.Release()
+	}
+
+	if m.Len() != 2 {
+		t.Errorf("Unexpected number of machines.\n\tExpected: %v\n\tGot: %v",
+			2, m.Len())
+	}
+
+	wantCounts := map[State]int{draft: 2}
+	if got := m.Counts(); !reflect.DeepEqual(wantCounts, got) {
+		t.Errorf("Unexpected counts.\n\tExpected: %v\n\tGot: %v",
+			wantCounts, got)
+	}
+
+	h, err := m.Get(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	h.Release()
+
+	wantLoads := map[int]int{1: 2, 2: 1, 3: 1, 4: 1}
+	if !reflect.DeepEqual(wantLoads, loads) {
+		t.Errorf("Unexpected loads.\n\tExpected: %v\n\tGot: %v",
+			wantLoads, loads)
+	}
+
+	wantCounts = map[State]int{draft: 1, confirmed: 1}
+	if got := m.Counts(); !reflect.DeepEqual(wantCounts, got) {
+		t.Errorf("Unexpected counts.\n\tExpected: %v\n\tGot: %v",
+			wantCounts, got)
+	}
+}
+
+func Test_ManagerLoaderPanicking(t *testing.T) {
+	def := NewDefinition()
+	def.From(0).On(1).To(1).Add()
+
+	panicking := true
+	m := NewManager(def, func(ctx context.Context, id int) (SMObject, error) {
+		if panicking {
+			panic("storage unavailable")
+		}
+		return &testObject{}, nil
+	}, 0)
+
+	if err := m.Do(1, 1); !errors.Is(err, ErrLoadPanicked) {
+		t.Errorf("Unexpected error.\n\tExpected: %v\n\tGot: %v",
+			ErrLoadPanicked, err)
+	}
+
+	if m.Len() != 0 {
+		t.Errorf("Unexpected number of machines.\n\tExpected: %v\n\tGot: %v",
+			0, m.Len())
+	}
+
+	panicking = false
+	done := make(chan error)
+	go func() { done <- m.Do(1, 1) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Do after a panicking load did not complete")
+	}
+}
+
+func Test_ManagerHandleNotEvicted(t *testing.T) {
+	def := NewDefinition()
+	def.From(0).On(1).To(1).Add()
+
+	loads := map[int]int{}
+	m := NewManager(def, func(ctx context.Context, id int) (SMObject, error) {
+		loads[id]++
+		return &testObject{}, nil
+	}, 1)
+
+	h, err := m.Get(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := m.Do(2, 1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := m.Do(1, 1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if expected, got := State(1), h.Object().State(); expected != got {
+		t.Errorf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+			expected, got)
+	}
+
+	wantLoads := map[int]int{1: 1, 2: 1}
+	if !reflect.DeepEqual(wantLoads, loads) {
+		t.Errorf("Unexpected loads.\n\tExpected: %v\n\tGot: %v",
+			wantLoads, loads)
+	}
+
+	h.Release()
+	h.Release()
+	if m.Len() != 1 {
+		t.Errorf("Unexpected number of machines.\n\tExpected: %v\n\tGot: %v",
+			1, m.Len())
+	}
+}
+
+func Test_ManagerConcurrentDo(t *testing.T) {
+	const (
+		idle State = iota
+		busy
+	)
+
+	const (
+		start CommandID = iota
+		stop
+	)
+
+	def := NewDefinition()
+	def.From(idle).On(start).To(busy).Add()
+	def.From(busy).On(stop).To(idle).Add()
+
+	var mu sync.Mutex
+	loads := map[int]int{}
+	m := NewManager(def, func(ctx context.Context, id int) (SMObject, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		loads[id]++
+		return &testObject{}, nil
+	}, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				m.Do(id, start)
+				m.Do(id, stop)
+			}
+		}(i % 5)
+	}
+	wg.Wait()
+
+	wantLoads := map[int]int{0: 1, 1: 1, 2: 1, 3: 1, 4: 1}
+	if !reflect.DeepEqual(wantLoads, loads) {
+		t.Errorf("Unexpected loads.\n\tExpected: %v\n\tGot: %v",
+			wantLoads, loads)
+	}
+}
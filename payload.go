@@ -0,0 +1,68 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+)
+
+type payloadKey struct{}
+
+// DoWith executes the command delivering the payload to its action and to the
+// conditions of its transitions.
+func (fsm StateMachine) DoWith(cmdID CommandID, payload interface{}) error {
+	return fsm.DoWithContext(context.Background(), cmdID, payload)
+}
+
+func (fsm StateMachine) DoWithContext(ctx context.Context, cmdID CommandID,
+	payload interface{}) error {
+
+	return fsm.DoContext(context.WithValue(ctx, payloadKey{}, payload), cmdID)
+}
+
+// Payload returns the payload of the command being executed, or nil when the
+// command was executed without payload.
+func Payload(ctx context.Context) interface{} {
+	return ctx.Value(payloadKey{})
+}
+
+// PayloadAction adapts an action receiving a typed payload. The action fails
+// when the command is executed with a payload of a different type, and
+// receives the zero value of P when executed without payload.
+func PayloadAction[P any](
+	action func(ctx context.Context, obj SMObject, payload P) error) ContextAction {
+
+	return func(ctx context.Context, obj SMObject) error {
+		payload, ok := typedPayload[P](ctx)
+		if !ok {
+			return fmt.Errorf("%w %T", ErrPayloadType, Payload(ctx))
+		}
+		return action(ctx, obj, payload)
+	}
+}
+
+// PayloadCondition adapts a condition receiving a typed payload. The
+// condition is not met when the command is executed with a payload of a
+// different type, and receives the zero value of P when executed without
+// payload.
+func PayloadCondition[P any](
+	cond func(ctx context.Context, obj SMObject, payload P) bool) ContextCondition {
+
+	return func(ctx context.Context, obj SMObject) bool {
+		payload, ok := typedPayload[P](ctx)
+		if !ok {
+			return false
+		}
+		return cond(ctx, obj, payload)
+	}
+}
+
+func typedPayload[P any](ctx context.Context) (P, bool) {
+	value := Payload(ctx)
+	if value == nil {
+		var zero P
+		return zero, true
+	}
+
+	payload, ok := value.(P)
+	return payload, ok
+}
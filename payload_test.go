@@ -0,0 +1,76 @@
+package fsm
+
+import (
+	"context"
+	"testing"
+)
+
+func Test_DoWith(t *testing.T) {
+	tests := []struct {
+		name      string
+		payload   interface{}
+		wantError bool
+		to        State
+	}{
+		{
+			name:    "amountOverLimit",
+			payload: 200,
+			to:      2,
+		},
+		{
+			name:    "amountUnderLimit",
+			payload: 50,
+			to:      1,
+		},
+		{
+			name:    "noPayload",
+			payload: nil,
+			to:      1,
+		},
+		{
+			name:      "wrongPayloadType",
+			payload:   "50",
+			wantError: true,
+			to:        0,
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			paid := 0
+			obj := &testObject{}
+			sm := New(obj)
+			sm.WithCommandContext(1, PayloadAction(
+				func(ctx context.Context, obj SMObject, amount int) error {
+					paid = amount
+					return nil
+				}))
+			sm.From(0).
+				On(1).IfContext(PayloadCondition(
+				func(ctx context.Context, obj SMObject, amount int) bool {
+					return amount > 100
+				})).To(2).Add().
+				On(1).Else().To(1).Add()
+
+			err := sm.DoWith(1, test.payload)
+			if test.wantError {
+				if err == nil {
+					t.Errorf("Expected error not found ")
+				}
+			} else if err != nil {
+				t.Errorf("Unexpected error found: %s ", err.Error())
+			}
+
+			if amount, ok := test.payload.(int); ok && amount != paid {
+				t.Errorf("Unexpected payload.\n\tExpected: %v\n\tGot: %v",
+					amount, paid)
+			}
+
+			if expected, got := test.to, obj.State(); expected != got {
+				t.Errorf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package fsm
+
+import "sort"
+
+// CanDo reports whether the command can be executed from the current state
+// of the object. Conditions are evaluated against the current object, without
+// executing the command action.
+func (fsm StateMachine) CanDo(cmdID CommandID) bool {
+	fsm.rt.mu.Lock()
+	defer fsm.rt.mu.Unlock()
+	return fsm.canDo(cmdID)
+}
+
+func (fsm StateMachine) canDo(cmdID CommandID) bool {
+	return len(fsm.possibleTargets(cmdID)) > 0
+}
+
+// AvailableCommands returns, in ascending order, the commands that can be
+// executed from the current state of the object.
+func (fsm StateMachine) AvailableCommands() []CommandID {
+	fsm.rt.mu.Lock()
+	defer fsm.rt.mu.Unlock()
+
+	cmds := []CommandID{}
+	seen := map[CommandID]bool{}
+	for cmdID := range fsm.def.wildcard {
+		if fsm.canDo(cmdID) {
+			cmds = append(cmds, cmdID)
+		}
+		seen[cmdID] = true
+	}
+
+	for _, leaf := range fsm.configuration() {
+		for _, s := range fsm.def.path(leaf) {
+			for cmdID := range fsm.def.transitions[s] {
+				if !seen[cmdID] && fsm.canDo(cmdID) {
+					cmds = append(cmds, cmdID)
+				}
+				seen[cmdID] = true
+			}
+		}
+	}
+
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i] < cmds[j] })
+	return cmds
+}
+
+// PossibleTargets returns, in evaluation order, the states reachable from the
+// current state of the object with the command whose conditions are currently
+// met.
+func (fsm StateMachine) PossibleTargets(cmdID CommandID) []State {
+	fsm.rt.mu.Lock()
+	defer fsm.rt.mu.Unlock()
+	return fsm.possibleTargets(cmdID)
+}
+
+func (fsm StateMachine) possibleTargets(cmdID CommandID) []State {
+	states := []State{}
+	ctx := fsm.context()
+	for _, step := range fsm.def.steps(fsm.configuration(), cmdID) {
+		for _, target := range step.targets {
+			if target.Condition != nil && !target.Condition(ctx, fsm.smObject) {
+				continue
+			}
+			if target.Internal {
+				states = append(states, step.from)
+				continue
+			}
+			states = append(states, target.State)
+		}
+	}
+
+	return states
+}
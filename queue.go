@@ -0,0 +1,176 @@
+package fsm
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const defaultMaxChain = 100
+
+type Event struct {
+	Command CommandID
+	Payload interface{}
+}
+
+// runtime holds the execution state of a machine, shared by its copies. Its
+// mutex serialises the commands and queries of the machine. The queue has its
+// own so events can be raised from any goroutine.
+type runtime struct {
+	mu       sync.Mutex
+	config   []State
+	deferred []Event
+	entered  map[State]time.Time
+	history  map[State][]State
+
+	queueMu sync.Mutex
+	queue   []Event
+}
+
+type runtimeKey struct{}
+
+// WithMaxChain sets the maximum number of events raised by actions that are
+// processed after a command. Beyond it, Do fails with ErrChainTooLong.
+func (d *Definition) WithMaxChain(n int) *Definition {
+	d.mustNotBeSealed()
+	d.maxChain = n
+	return d
+}
+
+func (fsm *StateMachine) WithMaxChain(n int) *StateMachine {
+	fsm.def.WithMaxChain(n)
+	return fsm
+}
+
+// Raise queues the command to be executed once the command being executed,
+// and the events raised before, complete. Commands executed with DoContext
+// and the context of an action are queued in the same way.
+// Commands raised while no command is being executed are executed after the
+// next one, or kept for the following one when it fails.
+func (fsm StateMachine) Raise(cmdID CommandID) {
+	fsm.RaiseWith(cmdID, nil)
+}
+
+func (fsm StateMachine) RaiseWith(cmdID CommandID, payload interface{}) {
+	fsm.rt.push(Event{Command: cmdID, Payload: payload})
+}
+
+// Raise queues the command in the machine executing the command of the
+// context. It reports whether the context belongs to a machine.
+func Raise(ctx context.Context, cmdID CommandID) bool {
+	return RaiseWith(ctx, cmdID, nil)
+}
+
+func RaiseWith(ctx context.Context, cmdID CommandID, payload interface{}) bool {
+	rt, ok := ctx.Value(runtimeKey{}).(*runtime)
+	if !ok {
+		return false
+	}
+
+	rt.push(Event{Command: cmdID, Payload: payload})
+	return true
+}
+
+func (rt *runtime) push(events ...Event) {
+	rt.queueMu.Lock()
+	defer rt.queueMu.Unlock()
+	rt.queue = append(rt.queue, events...)
+}
+
+func (rt *runtime) len() int {
+	rt.queueMu.Lock()
+	defer rt.queueMu.Unlock()
+	return len(rt.queue)
+}
+
+// truncate discards the events raised after the first n.
+func (rt *runtime) truncate(n int) {
+	rt.queueMu.Lock()
+	defer rt.queueMu.Unlock()
+	if n < len(rt.queue) {
+		rt.queue = rt.queue[:n]
+	}
+}
+
+func (rt *runtime) pop() (Event, bool) {
+	rt.queueMu.Lock()
+	defer rt.queueMu.Unlock()
+
+	if len(rt.queue) == 0 {
+		return Event{}, false
+	}
+
+	event := rt.queue[0]
+	rt.queue = rt.queue[1:]
+	return event, true
+}
+
+// run executes the command and then the deferred commands that can be
+// executed and the events raised by the actions, until there are none left.
+// Deferred commands failing are deferred again, without failing the command.
+// Commands executed with the context of the actions of the machine are
+// queued, and the others wait for the machine to be idle.
+func (fsm StateMachine) run(ctx context.Context, cmdID CommandID) error {
+	rt := fsm.rt
+	if running, ok := ctx.Value(runtimeKey{}).(*runtime); ok && running == rt {
+		rt.push(Event{Command: cmdID, Payload: Payload(ctx)})
+		return nil
+	}
+
+	if _, err := fsm.def.Build(); err != nil {
+		return err
+	}
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	// events raised before the command are kept when it fails, the ones
+	// raised while executing it are discarded
+	idle := rt.len()
+	defer func() { rt.truncate(idle) }()
+
+	ctx = context.WithValue(ctx, runtimeKey{}, rt)
+	if err := fsm.fire(ctx, cmdID); err != nil {
+		return err
+	}
+
+	var failed []Event
+	defer func() {
+		if len(failed) > 0 {
+			fsm.setDeferred(append(failed, fsm.deferred()...))
+		}
+	}()
+
+	for chain := 0; ; chain++ {
+		event, recalled := fsm.recall(ctx)
+		if !recalled {
+			var ok bool
+			if event, ok = rt.pop(); !ok {
+				break
+			}
+			if idle > 0 {
+				idle--
+			}
+		}
+
+		if chain >= fsm.def.maxChain {
+			if recalled {
+				failed = append(failed, event)
+			}
+			return newTransitionError(ErrChainTooLong,
+				fsm.configuration()[0], event.Command, nil, nil)
+		}
+
+		raised := rt.len()
+		eventCtx := context.WithValue(ctx, payloadKey{}, event.Payload)
+		if err := fsm.fire(eventCtx, event.Command); err != nil {
+			if !recalled {
+				return err
+			}
+			rt.truncate(raised)
+			failed = append(failed, event)
+		}
+	}
+
+	return nil
+}
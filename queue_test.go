@@ -0,0 +1,120 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func Test_RaisedEvents(t *testing.T) {
+	tests := []struct {
+		name      string
+		cmdID     CommandID
+		maxChain  int
+		to        State
+		wantError error
+	}{
+		{
+			name:  "raisedFromContext",
+			cmdID: 1,
+			to:    2,
+		},
+		{
+			name:  "reentrantDo",
+			cmdID: 3,
+			to:    2,
+		},
+		{
+			name:      "chainTooLong",
+			cmdID:     4,
+			maxChain:  5,
+			to:        3,
+			wantError: ErrChainTooLong,
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			states := []State{}
+			obj := &testObject{}
+			sm := New(obj)
+			if test.maxChain > 0 {
+				sm.WithMaxChain(test.maxChain)
+			}
+
+			sm.WithCommandContext(1, func(ctx context.Context, o SMObject) error {
+				states = append(states, o.State())
+				Raise(ctx, 2)
+				return nil
+			}).WithCommand(2, func() error {
+				states = append(states, obj.State())
+				return nil
+			}).WithCommandContext(3, func(ctx context.Context, o SMObject) error {
+				states = append(states, o.State())
+				return sm.DoContext(ctx, 2)
+			}).WithCommand(4, func() error {
+				sm.Raise(4)
+				return nil
+			})
+
+			sm.From(0).
+				On(1).To(1).Add().
+				On(3).To(1).Add().
+				On(4).To(3).Add()
+			sm.From(1).On(2).To(2).Add()
+			sm.From(3).On(4).To(3).Add()
+
+			err := sm.Do(test.cmdID)
+			if !errors.Is(err, test.wantError) {
+				t.Fatalf("Unexpected error.\n\tExpected: %v\n\tGot: %v",
+					test.wantError, err)
+			}
+
+			if expected, got := test.to, obj.State(); expected != got {
+				t.Errorf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+
+			if test.wantError == nil && (len(states) != 2 || states[1] != 1) {
+				t.Errorf("Raised event executed before the transition "+
+					"completed: %v", states)
+			}
+		})
+	}
+}
+
+func Test_RaisedWhileIdle(t *testing.T) {
+	obj := &testObject{}
+	sm := New(obj)
+	sm.WithCommandContext(1, func(ctx context.Context, o SMObject) error {
+		Raise(ctx, 9)
+		return nil
+	})
+	sm.From(0).On(1).To(1).Add()
+	sm.From(1).On(2).To(2).Add()
+
+	sm.Raise(2)
+
+	if err := sm.Do(9); !errors.Is(err, ErrUnknownCommand) {
+		t.Fatalf("Unexpected error.\n\tExpected: %v\n\tGot: %v",
+			ErrUnknownCommand, err)
+	}
+
+	// the raised command is executed after the next command, and the unknown
+	// command raised by its action is discarded once it fails
+	if err := sm.Do(1); !errors.Is(err, ErrUnknownCommand) {
+		t.Fatalf("Unexpected error.\n\tExpected: %v\n\tGot: %v",
+			ErrUnknownCommand, err)
+	}
+
+	if expected, got := State(2), obj.State(); expected != got {
+		t.Errorf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+			expected, got)
+	}
+
+	if err := sm.Do(2); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("Unexpected error.\n\tExpected: %v\n\tGot: %v",
+			ErrNotAllowed, err)
+	}
+}
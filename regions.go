@@ -0,0 +1,177 @@
+package fsm
+
+import "context"
+
+// ConfigurationObject is implemented by objects handled by machines with
+// orthogonal regions, persisting all the active states instead of a single
+// one. When the configuration is empty the state of the object is used. The
+// active states of other objects are only remembered by the machine, while
+// their state is the first of them.
+type ConfigurationObject interface {
+	SMObject
+	Configuration() []State
+	SetConfiguration([]State)
+}
+
+// Region declares an orthogonal region of the parent state made of the given
+// substates, the first of them being the initial state of the region. While
+// the parent state is active, every region has its own active state, and
+// commands are dispatched to all of them.
+func (d *Definition) Region(parent State, initial State,
+	states ...State) *Definition {
+
+	d.mustNotBeSealed()
+	d.Substates(parent, initial)
+	d.Substates(parent, states...)
+
+	region := len(d.regions[parent])
+	d.regions[parent] = append(d.regions[parent], initial)
+	d.regionOf[initial] = region
+	for _, s := range states {
+		d.regionOf[s] = region
+	}
+	return d
+}
+
+func (fsm *StateMachine) Region(parent State, initial State,
+	states ...State) *StateMachine {
+
+	fsm.def.Region(parent, initial, states...)
+	return fsm
+}
+
+// Configuration returns the active states of the object, one per active
+// orthogonal region.
+func (fsm StateMachine) Configuration() []State {
+	fsm.rt.mu.Lock()
+	defer fsm.rt.mu.Unlock()
+	return fsm.configuration()
+}
+
+func (fsm StateMachine) configuration() []State {
+	if obj, ok := fsm.smObject.(ConfigurationObject); ok {
+		if config := obj.Configuration(); len(config) > 0 {
+			return append([]State(nil), config...)
+		}
+	}
+
+	s := fsm.smObject.State()
+	if len(fsm.rt.config) > 0 && fsm.rt.config[0] == s {
+		return append([]State(nil), fsm.rt.config...)
+	}
+	return []State{s}
+}
+
+func (fsm StateMachine) setConfiguration(config []State) {
+	if obj, ok := fsm.smObject.(ConfigurationObject); ok {
+		obj.SetConfiguration(append([]State(nil), config...))
+		return
+	}
+
+	fsm.rt.config = nil
+	if len(config) > 1 {
+		fsm.rt.config = append([]State(nil), config...)
+	}
+	fsm.smObject.SetState(config[0])
+}
+
+type step struct {
+	from    State
+	targets Targets
+	target  Target
+}
+
+// steps returns the transitions of the command to evaluate from every active
+// state of the configuration. Every orthogonal region handles the command
+// independently, transitions of the states containing the regions are only
+// evaluated when no region handles it, and transitions from any state when
+// no state does.
+func (d *Definition) steps(config []State, cmdID CommandID) []step {
+	for level := 0; ; level++ {
+		var steps []step
+		seen := map[State]bool{}
+		deeper := false
+
+		for _, leaf := range config {
+			segments := d.segments(leaf)
+			if level >= len(segments) {
+				continue
+			}
+
+			deeper = true
+			segment := segments[level]
+			if seen[segment[0]] {
+				continue
+			}
+			seen[segment[0]] = true
+
+			var targets Targets
+			for _, s := range segment {
+				targets = append(targets, d.transitions[s][cmdID]...)
+			}
+			if len(targets) > 0 {
+				steps = append(steps, step{from: leaf, targets: targets})
+			}
+		}
+
+		if len(steps) > 0 {
+			return steps
+		}
+		if !deeper {
+			return d.wildcardSteps(config, cmdID)
+		}
+	}
+}
+
+// wildcardSteps returns the transitions of the command from any state not
+// excluded by the active states.
+func (d *Definition) wildcardSteps(config []State, cmdID CommandID) []step {
+	if d.done(config) {
+		return nil
+	}
+
+	var targets Targets
+	for _, target := range d.wildcard[cmdID] {
+		excluded := false
+		for _, leaf := range config {
+			for _, s := range d.path(leaf) {
+				excluded = excluded || contains(target.Except, s)
+			}
+		}
+		if !excluded {
+			targets = append(targets, target)
+		}
+	}
+
+	if len(targets) == 0 {
+		return nil
+	}
+	return []step{{from: config[0], targets: targets}}
+}
+
+// segments splits the path of the state at the boundaries of the orthogonal
+// regions containing it.
+func (d *Definition) segments(s State) [][]State {
+	var segments [][]State
+	var segment []State
+	for _, state := range d.path(s) {
+		segment = append(segment, state)
+		if parent, ok := d.parents[state]; ok && len(d.regions[parent]) > 0 {
+			segments = append(segments, segment)
+			segment = nil
+		}
+	}
+	return append(segments, segment)
+}
+
+// resolve returns the steps with a target whose condition is met.
+func resolve(ctx context.Context, obj SMObject, steps []step) []step {
+	var taken []step
+	for _, step := range steps {
+		if target, ok := step.targets.resolve(ctx, obj); ok {
+			step.target = target
+			taken = append(taken, step)
+		}
+	}
+	return taken
+}
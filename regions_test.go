@@ -0,0 +1,173 @@
+package fsm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type configurationObject struct {
+	testObject
+	config []State
+}
+
+func (o *configurationObject) Configuration() []State {
+	return o.config
+}
+
+func (o *configurationObject) SetConfiguration(config []State) {
+	o.config = config
+}
+
+func Test_OrthogonalRegions(t *testing.T) {
+	const (
+		draft State = iota
+		open
+		pending
+		approved
+		unsigned
+		signed
+		closed
+	)
+
+	const (
+		confirm CommandID = iota
+		approve
+		sign
+		reset
+		abandon
+	)
+
+	tests := []struct {
+		name       string
+		config     []State
+		cmdID      CommandID
+		wantConfig []State
+		wantCalls  string
+	}{
+		{
+			name:       "enterRegions",
+			config:     []State{draft},
+			cmdID:      confirm,
+			wantConfig: []State{pending, unsigned},
+			wantCalls:  "exitDraft,enterOpen,enterPending,enterUnsigned",
+		},
+		{
+			name:       "firstRegion",
+			config:     []State{pending, unsigned},
+			cmdID:      approve,
+			wantConfig: []State{approved, unsigned},
+			wantCalls:  "exitPending,enterApproved",
+		},
+		{
+			name:       "secondRegion",
+			config:     []State{approved, unsigned},
+			cmdID:      sign,
+			wantConfig: []State{approved, signed},
+			wantCalls:  "exitUnsigned,enterSigned",
+		},
+		{
+			name:       "allRegions",
+			config:     []State{approved, signed},
+			cmdID:      reset,
+			wantConfig: []State{pending, unsigned},
+			wantCalls: "exitApproved,exitSigned,enterPending," +
+				"enterUnsigned",
+		},
+		{
+			name:       "leaveRegions",
+			config:     []State{approved, unsigned},
+			cmdID:      abandon,
+			wantConfig: []State{closed},
+			wantCalls:  "exitApproved,exitUnsigned,exitOpen,enterClosed",
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			calls := []string{}
+			record := func(call string) Action {
+				return func() error {
+					calls = append(calls, call)
+					return nil
+				}
+			}
+
+			obj := &configurationObject{config: test.config}
+			sm := New(obj)
+			sm.Region(open, pending, approved).
+				Region(open, unsigned, signed)
+
+			names := map[State]string{draft: "Draft", open: "Open",
+				pending: "Pending", approved: "Approved",
+				unsigned: "Unsigned", signed: "Signed", closed: "Closed"}
+			for s, name := range names {
+				sm.OnEnter(s, record("enter"+name)).
+					OnExit(s, record("exit"+name))
+			}
+
+			sm.From(draft).On(confirm).To(open).Add()
+			sm.From(open).On(abandon).To(closed).Add()
+			sm.From(pending).On(approve).To(approved).Add()
+			sm.From(approved).On(reset).To(pending).Add()
+			sm.From(unsigned).On(sign).To(signed).Add()
+			sm.From(signed).On(reset).To(unsigned).Add()
+
+			if err := sm.Do(test.cmdID); err != nil {
+				t.Fatalf("Unexpected error found: %s ", err.Error())
+			}
+
+			if expected, got := test.wantConfig, sm.Configuration(); !reflect.DeepEqual(expected, got) {
+				t.Errorf("Unexpected configuration.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+
+			if expected, got := test.wantCalls, strings.Join(calls, ","); expected != got {
+				t.Errorf("Unexpected calls.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+		})
+	}
+}
+
+func Test_OrthogonalRegionsOfPlainObjects(t *testing.T) {
+	const (
+		draft State = iota
+		open
+		pending
+		approved
+		unsigned
+		signed
+	)
+
+	const (
+		confirm CommandID = iota
+		approve
+		sign
+	)
+
+	obj := &testObject{state: draft}
+	sm := New(obj)
+	sm.Region(open, pending, approved).
+		Region(open, unsigned, signed)
+	sm.From(draft).On(confirm).To(open).Add()
+	sm.From(pending).On(approve).To(approved).Add()
+	sm.From(unsigned).On(sign).To(signed).Add()
+
+	for _, cmdID := range []CommandID{confirm, approve, sign} {
+		if err := sm.Do(cmdID); err != nil {
+			t.Fatalf("Unexpected error found: %s ", err.Error())
+		}
+	}
+
+	if expected, got := []State{approved, signed}, sm.Configuration(); !reflect.DeepEqual(expected, got) {
+		t.Errorf("Unexpected configuration.\n\tExpected: %v\n\tGot: %v",
+			expected, got)
+	}
+
+	if expected, got := approved, obj.State(); expected != got {
+		t.Errorf("Unexpected state.\n\tExpected: %v\n\tGot: %v",
+			expected, got)
+	}
+}
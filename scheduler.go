@@ -0,0 +1,88 @@
+package fsm
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Scheduler fires the timed transitions of a set of machines.
+type Scheduler struct {
+	clock    Clock
+	interval time.Duration
+	onError  func(StateMachine, error)
+
+	mu       sync.Mutex
+	machines []StateMachine
+	wake     chan struct{}
+}
+
+// NewScheduler returns a scheduler waiting with the clock for the next timed
+// transition to be due, and checking the machines at least once every
+// interval.
+func NewScheduler(clock Clock, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		clock:    clock,
+		interval: interval,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// OnError sets the function called with the errors returned when firing the
+// timed transitions of a machine.
+func (s *Scheduler) OnError(f func(StateMachine, error)) *Scheduler {
+	s.onError = f
+	return s
+}
+
+func (s *Scheduler) Add(sm StateMachine) {
+	s.mu.Lock()
+	s.machines = append(s.machines, sm)
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Scheduler) Remove(sm StateMachine) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, m := range s.machines {
+		if m.rt == sm.rt {
+			s.machines = append(s.machines[:i], s.machines[i+1:]...)
+			return
+		}
+	}
+}
+
+// Run fires the timed transitions of the machines until the context is done.
+func (s *Scheduler) Run(ctx context.Context) error {
+	for {
+		s.mu.Lock()
+		machines := append([]StateMachine(nil), s.machines...)
+		s.mu.Unlock()
+
+		now := s.clock.Now()
+		wait := s.interval
+		for _, sm := range machines {
+			if err := sm.FireTimeouts(ctx); err != nil && s.onError != nil {
+				s.onError(sm, err)
+			}
+
+			if at, ok := sm.NextTimeout(); ok && at.After(now) &&
+				at.Sub(now) < wait {
+				wait = at.Sub(now)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.wake:
+		case <-s.clock.After(wait):
+		}
+	}
+}
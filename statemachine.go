@@ -1,7 +1,8 @@
 package fsm
 
 import (
-	"fmt"
+	"context"
+	"sort"
 )
 
 type State uint32
@@ -9,10 +10,22 @@ type CommandID uint32
 type Action func() error
 type Condition func() bool
 
-type Commands map[CommandID]Action
-type Targets map[State]Condition
+type Commands map[CommandID]ContextAction
 type Transitions map[State]map[CommandID]Targets
 
+type Target struct {
+	State     State
+	Condition ContextCondition
+	Action    ContextAction
+	History   bool
+	Internal  bool
+	Priority  int
+	Fallback  bool
+	Except    []State
+}
+
+type Targets []Target
+
 type SMObject interface {
 	SetState(State)
 	State() State
@@ -20,74 +33,291 @@ type SMObject interface {
 
 type StateMachine struct {
 	smObject SMObject
-	commands Commands
-	transitions Transitions
+	def      *Definition
+	rt       *runtime
 }
 
-
 func New(element SMObject) StateMachine {
 	fsm := &StateMachine{
 		smObject: element,
-		transitions: Transitions{},
-		commands: Commands{},
+		def:      NewDefinition(),
+		rt:       &runtime{},
 	}
 
 	return *fsm
 }
 
 func (fsm *StateMachine) WithCommand(id CommandID, action Action) *StateMachine {
-	fsm.commands[id] = action
+	fsm.def.WithCommandContext(id, action.context())
 	return fsm
 }
 
+func (fsm *StateMachine) WithCommandContext(id CommandID,
+	action ContextAction) *StateMachine {
 
-func (fsm *StateMachine) From(s State) *TransitionBuilder {
-	t := &TransitionBuilder{
-		sm:   fsm,
-		from: s,
-	}
-	return t
+	fsm.def.WithCommandContext(id, action)
+	return fsm
+}
+
+func (fsm *StateMachine) From(states ...State) *TransitionBuilder {
+	return fsm.def.From(states...)
+}
+
+func (fsm *StateMachine) FromAny() *TransitionBuilder {
+	return fsm.def.FromAny()
+}
+
+// WithGuardsBeforeAction makes Do evaluate the transition conditions before
+// executing the command action, so the action only runs when a transition
+// can be taken.
+func (fsm *StateMachine) WithGuardsBeforeAction() *StateMachine {
+	fsm.def.WithGuardsBeforeAction()
+	return fsm
+}
+
+// Object returns the object handled by the machine.
+func (fsm StateMachine) Object() SMObject {
+	return fsm.smObject
+}
+
+// Definition returns the definition of the machine, which for machines
+// created with New can be modified until the machine is built.
+func (fsm StateMachine) Definition() *Definition {
+	return fsm.def
 }
 
 func (fsm StateMachine) Do(cmdID CommandID) error {
+	return fsm.DoContext(context.Background(), cmdID)
+}
 
-	from := fsm.smObject.State()
+// DoContext executes the command honouring the context. The transition is
+// not taken, and the state of the object is left unchanged, when the context
+// is done before or after executing the command action. The definition is
+// built by the first command, which fails when it is not valid. Called from
+// the actions of the machine with their context, the command is queued.
+func (fsm StateMachine) DoContext(ctx context.Context, cmdID CommandID) error {
+	return fsm.run(ctx, cmdID)
+}
 
-	if _, ok := fsm.transitions[fsm.smObject.State()]; !ok {
-		return fmt.Errorf("cannot execute requested command %v from state %v",
-			cmdID, from)
+func (fsm StateMachine) fire(ctx context.Context, cmdID CommandID) error {
+
+	config := fsm.configuration()
+	from := config[0]
+	steps := fsm.def.steps(config, cmdID)
+	version, versioned := fsm.version()
+
+	var targets Targets
+	for _, step := range steps {
+		targets = append(targets, step.targets...)
 	}
 
-	action, ok := fsm.commands[cmdID]
-	if !ok {
-		return fmt.Errorf("command %v not found", cmdID)
+	rollback := func() {}
+	fail := func(kind error, err error) error {
+		rollback()
+		return newTransitionError(kind, from, cmdID, targets, err)
 	}
 
-	if action == nil {
-		return fmt.Errorf("no action found for command %v", cmdID)
+	if err := ctx.Err(); err != nil {
+		return fail(ErrCancelled, err)
 	}
 
-	err := action()
-	if err != nil {
-		return fmt.Errorf("command %v from status %v returned error: %v", 
-			cmdID, fsm.smObject.State(), err)
+	if !fsm.def.isKnown(cmdID) {
+		return fail(ErrUnknownCommand, nil)
 	}
-	
 
-	for toState, condition := range fsm.transitions[from][cmdID] {
-		if condition != nil {
-			if !condition() {
+	if len(steps) == 0 {
+		if fsm.def.defers(config, cmdID) {
+			fsm.setDeferred(append(fsm.deferred(),
+				Event{Command: cmdID, Payload: Payload(ctx)}))
+			return nil
+		}
+		if fsm.def.done(config) {
+			return fail(ErrFinalState, nil)
+		}
+		return fail(ErrNotAllowed, nil)
+	}
+
+	if fsm.def.guardsFirst {
+		steps = resolve(ctx, fsm.smObject, steps)
+		if len(steps) == 0 {
+			return fail(ErrGuardRejected, nil)
+		}
+	}
+
+	// exitSources exits the active states the transitions are taken from,
+	// once their conditions are met.
+	exited := map[State]bool{}
+	exitSources := func() error {
+		for _, step := range steps {
+			if step.target.Internal {
 				continue
 			}
+			if err := fsm.exit(ctx, []State{step.from}); err != nil {
+				return err
+			}
+			exited[step.from] = true
+		}
+		return nil
+	}
 
-			fsm.smObject.SetState(toState)
-			return nil
+	rollback = fsm.begin(config)
+
+	if fsm.def.guardsFirst {
+		if err := exitSources(); err != nil {
+			return fail(ErrActionFailed, err)
+		}
+	}
+
+	if err := fsm.runAction(ctx, fsm.def.commands[cmdID]); err != nil {
+		return fail(ErrActionFailed, err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return fail(ErrCancelled, err)
+	}
+
+	if !fsm.def.guardsFirst {
+		steps = resolve(ctx, fsm.smObject, steps)
+		if len(steps) == 0 {
+			return fail(ErrGuardRejected, nil)
+		}
+		if err := exitSources(); err != nil {
+			return fail(ErrActionFailed, err)
+		}
+	}
+
+	start := config
+	changed := false
+
+	for _, step := range steps {
+		if !contains(config, step.from) {
+			continue
+		}
+
+		if step.target.Internal {
+			if err := fsm.runAction(ctx, step.target.Action); err != nil {
+				return fail(ErrActionFailed, err)
+			}
+			if err := ctx.Err(); err != nil {
+				return fail(ErrCancelled, err)
+			}
+			continue
+		}
+
+		var remembered []State
+		if step.target.History {
+			remembered = fsm.remembered(step.target.State)
+		}
+
+		left, entered, next := fsm.def.transition(config, step.from,
+			step.target.State, remembered)
+
+		var pending []State
+		for _, s := range left {
+			if !exited[s] {
+				pending = append(pending, s)
+			}
+		}
+		if err := fsm.exit(ctx, pending); err != nil {
+			return fail(ErrActionFailed, err)
+		}
+
+		if err := fsm.runAction(ctx, step.target.Action); err != nil {
+			return fail(ErrActionFailed, err)
+		}
+
+		if err := ctx.Err(); err != nil {
+			return fail(ErrCancelled, err)
 		}
 
-		fsm.smObject.SetState(toState)
+		fsm.remember(config, left)
+		changed = true
+		config = next
+		fsm.setConfiguration(config)
+		fsm.track(left, entered)
+
+		if err := fsm.enter(ctx, entered); err != nil {
+			return fail(ErrActionFailed, err)
+		}
+	}
+
+	if fsm.def.done(config) {
+		for _, action := range fsm.def.complete {
+			if err := fsm.runAction(ctx, action); err != nil {
+				return fail(ErrActionFailed, err)
+			}
+		}
+	}
+
+	if versioned && changed {
+		if err := fsm.bump(version); err != nil {
+			fsm.setConfiguration(start)
+			return fail(ErrConflict, err)
+		}
+	}
+
+	return nil
+}
+
+func (fsm StateMachine) runAction(ctx context.Context,
+	action ContextAction) error {
+
+	if action == nil {
 		return nil
 	}
+	return action(ctx, fsm.smObject)
+}
+
+// resolve returns the first target whose condition is met.
+func (t Targets) resolve(ctx context.Context, obj SMObject) (Target, bool) {
+	for _, target := range t {
+		if target.Condition != nil && !target.Condition(ctx, obj) {
+			continue
+		}
+		return target, true
+	}
+	return Target{}, false
+}
 
-	return fmt.Errorf("cannot find executable transition for command %v "+
-		"and state %v", cmdID, from)
+// add keeps targets in evaluation order: regular targets before fallbacks,
+// higher priority first and declaration order within the same priority.
+func (t Targets) add(target Target) Targets {
+	t = append(t, target)
+	sort.SliceStable(t, func(i, j int) bool {
+		if t[i].Fallback != t[j].Fallback {
+			return !t[i].Fallback
+		}
+		return t[i].Priority > t[j].Priority
+	})
+	return t
+}
+
+func (a Action) object() ObjectAction {
+	if a == nil {
+		return nil
+	}
+	return func(SMObject) error { return a() }
+}
+
+func (a Action) context() ContextAction {
+	if a == nil {
+		return nil
+	}
+	return func(context.Context, SMObject) error { return a() }
+}
+
+func (c Condition) context() ContextCondition {
+	if c == nil {
+		return nil
+	}
+	return func(context.Context, SMObject) bool { return c() }
+}
+
+func contains(states []State, s State) bool {
+	for _, state := range states {
+		if state == s {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,235 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type testObject struct {
+	state State
+}
+
+func (o *testObject) SetState(s State) {
+	o.state = s
+}
+
+func (o *testObject) State() State {
+	return o.state
+}
+
+func Test_TargetsEvaluationOrder(t *testing.T) {
+	always := func() bool { return true }
+
+	tests := []struct {
+		name  string
+		build func(sm *StateMachine)
+		to    State
+	}{
+		{
+			name: "declarationOrder",
+			build: func(sm *StateMachine) {
+				sm.From(0).
+					On(1).If(always).To(1).Add().
+					On(1).To(2).Add().
+					On(1).If(always).To(3).Add()
+			},
+			to: 1,
+		},
+		{
+			name: "priority",
+			build: func(sm *StateMachine) {
+				sm.From(0).
+					On(1).If(always).To(1).Add().
+					On(1).If(always).Priority(1).To(2).Add().
+					On(1).If(always).Priority(2).To(3).Add()
+			},
+			to: 3,
+		},
+		{
+			name: "else",
+			build: func(sm *StateMachine) {
+				sm.From(0).
+					On(1).Else().To(1).Add().
+					On(1).If(always).To(2).Add()
+			},
+			to: 2,
+		},
+		{
+			name: "elseWhenNoConditionMet",
+			build: func(sm *StateMachine) {
+				sm.From(0).
+					On(1).Else().To(1).Add().
+					On(1).If(func() bool { return false }).To(2).Add()
+			},
+			to: 1,
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				obj := &testObject{}
+				sm := New(obj)
+				sm.WithCommand(1, func() error { return nil })
+				test.build(&sm)
+
+				if err := sm.Do(1); err != nil {
+					t.Fatalf("Unexpected error found: %s ", err.Error())
+				}
+
+				if expected, got := test.to, obj.State(); expected != got {
+					t.Fatalf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+						expected, got)
+				}
+			}
+		})
+	}
+}
+
+func Test_DoDoesNotRunActionWhenNotAllowed(t *testing.T) {
+	never := func() bool { return false }
+
+	tests := []struct {
+		name        string
+		cmdID       CommandID
+		guardsFirst bool
+		wantAction  bool
+	}{
+		{
+			name:        "commandNotAllowed",
+			cmdID:       2,
+			guardsFirst: false,
+			wantAction:  false,
+		},
+		{
+			name:        "guardRejected",
+			cmdID:       1,
+			guardsFirst: false,
+			wantAction:  true,
+		},
+		{
+			name:        "guardRejected.GuardsFirst",
+			cmdID:       1,
+			guardsFirst: true,
+			wantAction:  false,
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			executed := false
+			action := func() error {
+				executed = true
+				return nil
+			}
+
+			obj := &testObject{}
+			sm := New(obj)
+			sm.WithCommand(1, action).WithCommand(2, action)
+			if test.guardsFirst {
+				sm.WithGuardsBeforeAction()
+			}
+			sm.From(0).On(1).If(never).To(1).Add()
+			sm.From(1).On(2).To(2).Add()
+
+			if err := sm.Do(test.cmdID); err == nil {
+				t.Errorf("Expected error not found ")
+			}
+
+			if expected, got := test.wantAction, executed; expected != got {
+				t.Errorf("Unexpected action execution.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+
+			if got := obj.State(); got != 0 {
+				t.Errorf("Unexpected state change to %v", got)
+			}
+		})
+	}
+}
+
+func Test_DoContext(t *testing.T) {
+	tests := []struct {
+		name         string
+		cancelBefore bool
+		cancelDuring bool
+		wantAction   bool
+		wantGuard    bool
+		wantError    error
+		to           State
+	}{
+		{
+			name:       "notCancelled",
+			wantAction: true,
+			wantGuard:  true,
+			to:         1,
+		},
+		{
+			name:         "cancelledBeforeAction",
+			cancelBefore: true,
+			wantAction:   false,
+			wantError:    context.Canceled,
+			to:           0,
+		},
+		{
+			name:         "cancelledDuringAction",
+			cancelDuring: true,
+			wantAction:   true,
+			wantError:    context.Canceled,
+			to:           0,
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			executed := false
+			guarded := false
+			obj := &testObject{}
+			sm := New(obj)
+			sm.WithCommandContext(1,
+				func(ctx context.Context, obj SMObject) error {
+					executed = true
+					if test.cancelDuring {
+						cancel()
+					}
+					return nil
+				})
+			sm.From(0).On(1).If(func() bool {
+				guarded = true
+				return true
+			}).To(1).Add()
+
+			if test.cancelBefore {
+				cancel()
+			}
+
+			err := sm.DoContext(ctx, 1)
+			if !errors.Is(err, test.wantError) {
+				t.Errorf("Unexpected error.\n\tExpected: %v\n\tGot: %v",
+					test.wantError, err)
+			}
+
+			if expected, got := test.wantAction, executed; expected != got {
+				t.Errorf("Unexpected action execution.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+
+			if expected, got := test.wantGuard, guarded; expected != got {
+				t.Errorf("Unexpected guard evaluation.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+
+			if expected, got := test.to, obj.State(); expected != got {
+				t.Errorf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+		})
+	}
+}
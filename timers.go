@@ -0,0 +1,201 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// TimedObject is implemented by objects persisting the time they entered
+// their active states, so timed transitions survive reloading them.
+type TimedObject interface {
+	EnteredAt() map[State]time.Time
+	SetEnteredAt(map[State]time.Time)
+}
+
+type timer struct {
+	from     State
+	anyState bool
+	cmdID    CommandID
+	after    time.Duration
+}
+
+// WithClock sets the clock used by timed transitions.
+func (d *Definition) WithClock(c Clock) *Definition {
+	d.mustNotBeSealed()
+	d.clock = c
+	return d
+}
+
+func (fsm *StateMachine) WithClock(c Clock) *StateMachine {
+	fsm.def.WithClock(c)
+	return fsm
+}
+
+// elapsed returns the condition met once the duration has elapsed since the
+// object entered the source state, or its current state for transitions
+// from any state.
+func (d *Definition) elapsed(tm timer) ContextCondition {
+	return func(ctx context.Context, obj SMObject) bool {
+		s := tm.from
+		if tm.anyState {
+			s = obj.State()
+		}
+
+		at, ok := enteredAt(ctx, obj)[s]
+		return ok && !d.clock.Now().Before(at.Add(tm.after))
+	}
+}
+
+func enteredAt(ctx context.Context, obj SMObject) map[State]time.Time {
+	if obj, ok := obj.(TimedObject); ok {
+		return obj.EnteredAt()
+	}
+	if rt, ok := ctx.Value(runtimeKey{}).(*runtime); ok {
+		return rt.entered
+	}
+	return nil
+}
+
+// track records the time the object entered its active states.
+func (fsm StateMachine) track(left, entered []State) {
+	if len(fsm.def.timers) == 0 {
+		return
+	}
+
+	now := fsm.def.clock.Now()
+	times := map[State]time.Time{}
+	for s, at := range enteredAt(fsm.context(), fsm.smObject) {
+		if !contains(left, s) {
+			times[s] = at
+		}
+	}
+	for _, s := range entered {
+		times[s] = now
+	}
+
+	fsm.setEnteredAt(times)
+}
+
+func (fsm StateMachine) setEnteredAt(times map[State]time.Time) {
+	if obj, ok := fsm.smObject.(TimedObject); ok {
+		obj.SetEnteredAt(times)
+		return
+	}
+	fsm.rt.entered = times
+}
+
+type timeout struct {
+	cmdID CommandID
+	at    time.Time
+}
+
+// timeouts returns the timed transitions from the active states, in the order
+// they are due. Active states whose entry time is unknown start counting now.
+func (fsm StateMachine) timeouts() []timeout {
+	config := fsm.configuration()
+	if len(fsm.def.timers) == 0 || fsm.def.done(config) {
+		return nil
+	}
+
+	active := []State{}
+	for _, leaf := range config {
+		for _, s := range fsm.def.path(leaf) {
+			if !contains(active, s) {
+				active = append(active, s)
+			}
+		}
+	}
+
+	times := enteredAt(fsm.context(), fsm.smObject)
+	started := false
+	var timeouts []timeout
+	for _, tm := range fsm.def.timers {
+		s := tm.from
+		if tm.anyState {
+			s = fsm.smObject.State()
+		} else if !contains(active, s) {
+			continue
+		}
+
+		at, ok := times[s]
+		if !ok {
+			if !started {
+				times = copyTimes(times)
+				started = true
+			}
+			at = fsm.def.clock.Now()
+			times[s] = at
+		}
+
+		timeouts = append(timeouts, timeout{cmdID: tm.cmdID,
+			at: at.Add(tm.after)})
+	}
+
+	if started {
+		fsm.setEnteredAt(times)
+	}
+
+	sort.SliceStable(timeouts, func(i, j int) bool {
+		return timeouts[i].at.Before(timeouts[j].at)
+	})
+	return timeouts
+}
+
+func (fsm StateMachine) lockedTimeouts() []timeout {
+	fsm.rt.mu.Lock()
+	defer fsm.rt.mu.Unlock()
+	return fsm.timeouts()
+}
+
+// NextTimeout returns the time the next timed transition from the active
+// states is due.
+func (fsm StateMachine) NextTimeout() (time.Time, bool) {
+	timeouts := fsm.lockedTimeouts()
+	if len(timeouts) == 0 {
+		return time.Time{}, false
+	}
+	return timeouts[0].at, true
+}
+
+// FireTimeouts executes the commands of the timed transitions that are due.
+// Commands that can no longer be executed, because a previous one changed
+// the state or their conditions are not met, are ignored without executing
+// their actions.
+func (fsm StateMachine) FireTimeouts(ctx context.Context) error {
+	timeouts := fsm.lockedTimeouts()
+
+	now := fsm.def.clock.Now()
+	fired := map[CommandID]bool{}
+	for _, t := range timeouts {
+		if t.at.After(now) || fired[t.cmdID] {
+			continue
+		}
+		fired[t.cmdID] = true
+
+		if !fsm.CanDo(t.cmdID) {
+			continue
+		}
+
+		err := fsm.DoContext(ctx, t.cmdID)
+		if err != nil && !errors.Is(err, ErrGuardRejected) &&
+			!errors.Is(err, ErrNotAllowed) && !errors.Is(err, ErrFinalState) {
+			return err
+		}
+	}
+	return nil
+}
+
+// context returns a context giving conditions access to the machine.
+func (fsm StateMachine) context() context.Context {
+	return context.WithValue(context.Background(), runtimeKey{}, fsm.rt)
+}
+
+func copyTimes(times map[State]time.Time) map[State]time.Time {
+	c := make(map[State]time.Time, len(times))
+	for s, at := range times {
+		c[s] = at
+	}
+	return c
+}
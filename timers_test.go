@@ -0,0 +1,226 @@
+package fsm
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type timedObject struct {
+	testObject
+	enteredAt map[State]time.Time
+}
+
+func (o *timedObject) EnteredAt() map[State]time.Time {
+	return o.enteredAt
+}
+
+func (o *timedObject) SetEnteredAt(times map[State]time.Time) {
+	o.enteredAt = times
+}
+
+func Test_TimedTransitions(t *testing.T) {
+	const (
+		draft State = iota
+		waiting
+		overdue
+		paid
+	)
+
+	const (
+		confirm CommandID = iota
+		markOverdue
+		pay
+	)
+
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		obj     SMObject
+		elapsed []time.Duration
+		paid    bool
+		to      State
+	}{
+		{
+			name:    "notDue",
+			obj:     &testObject{},
+			elapsed: []time.Duration{29 * 24 * time.Hour},
+			to:      waiting,
+		},
+		{
+			name:    "due",
+			obj:     &testObject{},
+			elapsed: []time.Duration{30 * 24 * time.Hour},
+			to:      overdue,
+		},
+		{
+			name: "dueInSteps",
+			obj:  &testObject{},
+			elapsed: []time.Duration{15 * 24 * time.Hour,
+				15 * 24 * time.Hour},
+			to: overdue,
+		},
+		{
+			name:    "due.Object",
+			obj:     &timedObject{},
+			elapsed: []time.Duration{30 * 24 * time.Hour},
+			to:      overdue,
+		},
+		{
+			name:    "leftBeforeDue",
+			obj:     &testObject{},
+			elapsed: []time.Duration{30 * 24 * time.Hour},
+			paid:    true,
+			to:      paid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := NewManualClock(start)
+			sm := New(test.obj)
+			sm.WithClock(clock)
+			sm.From(draft).On(confirm).To(waiting).Add()
+			sm.From(waiting).
+				On(markOverdue).After(30 * 24 * time.Hour).To(overdue).Add().
+				On(pay).To(paid).Add()
+
+			if err := sm.Do(confirm); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if test.paid {
+				if err := sm.Do(pay); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+
+			for _, d := range test.elapsed {
+				clock.Advance(d)
+				if err := sm.FireTimeouts(context.Background()); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+
+			if test.obj.State() != test.to {
+				t.Errorf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+					test.to, test.obj.State())
+			}
+		})
+	}
+}
+
+func Test_TimedTransitionsBeforeDue(t *testing.T) {
+	const (
+		waiting State = iota
+		overdue
+	)
+
+	const markOverdue CommandID = 0
+
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	obj := &testObject{state: waiting}
+	sm := New(obj)
+	sm.WithClock(clock)
+	sm.From(waiting).On(markOverdue).After(time.Hour).To(overdue).Add()
+
+	if sm.CanDo(markOverdue) {
+		t.Errorf("Unexpected CanDo before the transition is due")
+	}
+
+	next, ok := sm.NextTimeout()
+	if !ok || !next.Equal(start.Add(time.Hour)) {
+		t.Errorf("Unexpected next timeout.\n\tExpected: %v\n\tGot: %v",
+			start.Add(time.Hour), next)
+	}
+
+	clock.Advance(time.Hour)
+	if !sm.CanDo(markOverdue) {
+		t.Errorf("Unexpected CanDo when the transition is due")
+	}
+}
+
+func Test_TimedTransitionsGuardRejected(t *testing.T) {
+	const (
+		waiting State = iota
+		overdue
+	)
+
+	const markOverdue CommandID = 0
+
+	clock := NewManualClock(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	obj := &testObject{state: waiting}
+	calls := 0
+	sm := New(obj)
+	sm.WithClock(clock)
+	sm.WithCommand(markOverdue, func() error {
+		calls++
+		return nil
+	})
+	sm.From(waiting).On(markOverdue).After(time.Hour).
+		If(func() bool { return false }).To(overdue).Add()
+
+	// the entry time of the state is unknown, so it starts counting now
+	if _, ok := sm.NextTimeout(); !ok {
+		t.Fatalf("Expected next timeout not found")
+	}
+
+	clock.Advance(time.Hour)
+	for i := 0; i < 3; i++ {
+		if err := sm.FireTimeouts(context.Background()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if calls != 0 {
+		t.Errorf("Unexpected action calls.\n\tExpected: %v\n\tGot: %v",
+			0, calls)
+	}
+
+	if expected, got := waiting, obj.State(); expected != got {
+		t.Errorf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+			expected, got)
+	}
+}
+
+func Test_Scheduler(t *testing.T) {
+	const (
+		waiting State = iota
+		overdue
+	)
+
+	const markOverdue CommandID = 0
+
+	clock := NewManualClock(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	obj := &testObject{state: waiting}
+	entered := make(chan struct{})
+	sm := New(obj)
+	sm.WithClock(clock)
+	sm.From(waiting).On(markOverdue).After(time.Hour).To(overdue).Add()
+	sm.OnEnter(overdue, func() error {
+		close(entered)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	scheduler := NewScheduler(clock, time.Minute)
+	scheduler.Add(sm)
+	done := make(chan error)
+	go func() { done <- scheduler.Run(ctx) }()
+
+	for {
+		select {
+		case <-entered:
+			cancel()
+			if err := <-done; err != context.Canceled {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			return
+		case <-time.After(time.Millisecond):
+			clock.Advance(time.Minute)
+		}
+	}
+}
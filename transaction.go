@@ -0,0 +1,41 @@
+package fsm
+
+// Snapshotter is implemented by objects able to take a snapshot of
+// themselves, including their state, and to restore it later.
+type Snapshotter interface {
+	Snapshot() interface{}
+	Restore(snapshot interface{})
+}
+
+// WithTransactions makes Do restore the object to the snapshot taken before
+// executing the command whenever the transition does not complete. Only
+// objects implementing Snapshotter are restored.
+func (d *Definition) WithTransactions() *Definition {
+	d.mustNotBeSealed()
+	d.transactional = true
+	return d
+}
+
+func (fsm *StateMachine) WithTransactions() *StateMachine {
+	fsm.def.WithTransactions()
+	return fsm
+}
+
+// begin takes a snapshot of the object, when transactions are enabled, and
+// returns the function restoring it.
+func (fsm StateMachine) begin(config []State) (rollback func()) {
+	if !fsm.def.transactional {
+		return func() {}
+	}
+
+	s, ok := fsm.smObject.(Snapshotter)
+	if !ok {
+		return func() {}
+	}
+
+	snapshot := s.Snapshot()
+	return func() {
+		s.Restore(snapshot)
+		fsm.setConfiguration(config)
+	}
+}
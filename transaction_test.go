@@ -0,0 +1,94 @@
+package fsm
+
+import (
+	"errors"
+	"testing"
+)
+
+type snapshotObject struct {
+	testObject
+	counter int
+}
+
+func (o *snapshotObject) Snapshot() interface{} {
+	return *o
+}
+
+func (o *snapshotObject) Restore(snapshot interface{}) {
+	*o = snapshot.(snapshotObject)
+}
+
+func Test_Transactions(t *testing.T) {
+	tests := []struct {
+		name          string
+		transactional bool
+		cmdID         CommandID
+		wantCounter   int
+		wantState     State
+	}{
+		{
+			name:          "guardRejected",
+			transactional: true,
+			cmdID:         1,
+			wantCounter:   0,
+			wantState:     0,
+		},
+		{
+			name:          "guardRejected.NotTransactional",
+			transactional: false,
+			cmdID:         1,
+			wantCounter:   1,
+			wantState:     0,
+		},
+		{
+			name:          "entryFailed",
+			transactional: true,
+			cmdID:         2,
+			wantCounter:   0,
+			wantState:     0,
+		},
+		{
+			name:          "entryFailed.NotTransactional",
+			transactional: false,
+			cmdID:         2,
+			wantCounter:   1,
+			wantState:     2,
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			obj := &snapshotObject{}
+			increment := func() error {
+				obj.counter++
+				return nil
+			}
+
+			sm := New(obj)
+			if test.transactional {
+				sm.WithTransactions()
+			}
+			sm.WithCommand(1, increment).
+				WithCommand(2, increment).
+				OnEnter(2, func() error { return errors.New("entry") })
+			sm.From(0).
+				On(1).If(func() bool { return false }).To(1).Add().
+				On(2).To(2).Add()
+
+			if err := sm.Do(test.cmdID); err == nil {
+				t.Fatalf("Expected error not found ")
+			}
+
+			if expected, got := test.wantCounter, obj.counter; expected != got {
+				t.Errorf("Unexpected counter.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+
+			if expected, got := test.wantState, obj.State(); expected != got {
+				t.Errorf("Unexpected state.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+		})
+	}
+}
@@ -1,11 +1,31 @@
 package fsm
 
+import (
+	"fmt"
+	"time"
+)
+
 type TransitionBuilder struct {
-	sm        *StateMachine
-	from      State
+	def       *Definition
+	from      []State
+	anyState  bool
+	except    []State
 	to        State
 	cmdID     CommandID
-	condition Condition
+	condition ContextCondition
+	action    ContextAction
+	history   bool
+	internal  bool
+	after     time.Duration
+	priority  int
+	fallback  bool
+}
+
+// Except excludes the states from the source states of the transitions. For
+// transitions from any state, their substates are excluded too.
+func (t *TransitionBuilder) Except(states ...State) *TransitionBuilder {
+	t.except = append(t.except, states...)
+	return t
 }
 
 func (t *TransitionBuilder) To(s State) *TransitionBuilder {
@@ -13,30 +33,168 @@ func (t *TransitionBuilder) To(s State) *TransitionBuilder {
 	return t
 }
 
+// ToHistory sets as target the history pseudo-state of the state, entering
+// the substates last active instead of the initial ones.
+func (t *TransitionBuilder) ToHistory(s State) *TransitionBuilder {
+	t.to = s
+	t.history = true
+	return t
+}
+
 func (t *TransitionBuilder) On(cmd CommandID) *TransitionBuilder {
 	t.cmdID = cmd
 	return t
 }
 
 func (t *TransitionBuilder) If(cond Condition) *TransitionBuilder {
+	t.condition = cond.context()
+	return t
+}
+
+// IfObject sets a condition evaluated against the object handled by the
+// machine.
+func (t *TransitionBuilder) IfObject(cond ObjectCondition) *TransitionBuilder {
+	t.condition = cond.context()
+	return t
+}
+
+// IfContext sets a condition evaluated with the context of the executed
+// command against the object handled by the machine.
+func (t *TransitionBuilder) IfContext(cond ContextCondition) *TransitionBuilder {
 	t.condition = cond
 	return t
 }
 
+// Then sets an action executed only when this transition is taken, after
+// the command action.
+func (t *TransitionBuilder) Then(action Action) *TransitionBuilder {
+	t.action = action.context()
+	return t
+}
+
+func (t *TransitionBuilder) ThenObject(action ObjectAction) *TransitionBuilder {
+	t.action = action.context()
+	return t
+}
+
+func (t *TransitionBuilder) ThenContext(action ContextAction) *TransitionBuilder {
+	t.action = action
+	return t
+}
+
+// Internal makes the transition internal: its actions are executed without
+// leaving the source state, so no exit nor entry actions are executed and the
+// state of the object is not changed. Transitions are external by default,
+// and a transition to the source state leaves and enters it again.
+func (t *TransitionBuilder) Internal() *TransitionBuilder {
+	t.internal = true
+	return t
+}
+
+// After makes the transition timed: it can only be taken once the duration
+// has elapsed since the object entered the source state. Timed transitions
+// are taken by executing their command, which FireTimeouts and the
+// Scheduler do when they are due.
+func (t *TransitionBuilder) After(d time.Duration) *TransitionBuilder {
+	t.after = d
+	return t
+}
+
+// Priority sets the evaluation priority of the transition among those
+// declared for the same state and command. Higher values are evaluated first.
+func (t *TransitionBuilder) Priority(p int) *TransitionBuilder {
+	t.priority = p
+	return t
+}
+
+// Else marks the transition as fallback, evaluated only when no other
+// transition for the same state and command can be taken.
+func (t *TransitionBuilder) Else() *TransitionBuilder {
+	t.fallback = true
+	return t
+}
+
 func (t *TransitionBuilder) Add() *TransitionBuilder {
 
-	if _, ok := t.sm.transitions[t.from]; !ok {
-		t.sm.transitions[t.from] = map[CommandID]Targets{}
+	t.def.mustNotBeSealed()
+	t.add(t.target())
+
+	return t.next()
+}
+
+// ToChoice sets as target a choice pseudo-state, whose branches are
+// evaluated in order after executing the command action. The choice must be
+// completed with Otherwise, which adds it to the state machine.
+func (t *TransitionBuilder) ToChoice() *ChoiceBuilder {
+	t.def.mustNotBeSealed()
+	c := &ChoiceBuilder{transition: t}
+	t.def.choices = append(t.def.choices, c)
+	return c
+}
+
+func (t *TransitionBuilder) target() Target {
+	return Target{
+		State:     t.to,
+		Condition: t.condition,
+		Action:    t.action,
+		History:   t.history,
+		Internal:  t.internal,
+		Priority:  t.priority,
+		Fallback:  t.fallback,
+		Except:    t.except,
 	}
+}
 
-	if _, ok := t.sm.transitions[t.from][t.cmdID]; !ok {
-		t.sm.transitions[t.from][t.cmdID] = Targets{}
+func (t *TransitionBuilder) next() *TransitionBuilder {
+	return &TransitionBuilder{
+		def:      t.def,
+		from:     t.from,
+		anyState: t.anyState,
+		except:   t.except,
+	}
+}
+
+// add adds the target to the transitions from every source state.
+func (t *TransitionBuilder) add(target Target) {
+	if t.anyState {
+		t.def.wildcard[t.cmdID] = t.def.wildcard[t.cmdID].add(
+			t.timed(target, timer{anyState: true}))
+		return
 	}
 
-	t.sm.transitions[t.from][t.cmdID][t.to] = t.condition
+	for _, from := range t.from {
+		if contains(t.except, from) {
+			continue
+		}
+		target := t.timed(target, timer{from: from})
 
-	return &TransitionBuilder{
-		sm:   t.sm,
-		from: t.from,
+		if _, ok := t.def.transitions[from]; !ok {
+			t.def.transitions[from] = map[CommandID]Targets{}
+		}
+
+		t.def.transitions[from][t.cmdID] =
+			t.def.transitions[from][t.cmdID].add(target)
+	}
+}
+
+// timed adds to the target of a timed transition the condition checking its
+// time has elapsed.
+func (t *TransitionBuilder) timed(target Target, tm timer) Target {
+	if t.after <= 0 {
+		return target
+	}
+
+	tm.cmdID = t.cmdID
+	tm.after = t.after
+	t.def.timers = append(t.def.timers, tm)
+
+	target.Condition = and(t.def.elapsed(tm), target.Condition)
+	return target
+}
+
+func (t *TransitionBuilder) source() string {
+	if t.anyState {
+		return "any state"
 	}
+	return fmt.Sprintf("states %v", t.from)
 }
@@ -0,0 +1,169 @@
+package fsm
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_TransitionActions(t *testing.T) {
+	tests := []struct {
+		name      string
+		from      State
+		global    bool
+		wantCalls string
+	}{
+		{
+			name:      "from0",
+			from:      0,
+			global:    false,
+			wantCalls: "then0",
+		},
+		{
+			name:      "from1",
+			from:      1,
+			global:    false,
+			wantCalls: "then1",
+		},
+		{
+			name:      "from2.NoTransitionAction",
+			from:      2,
+			global:    false,
+			wantCalls: "",
+		},
+		{
+			name:      "from0.Global",
+			from:      0,
+			global:    true,
+			wantCalls: "global,then0",
+		},
+		{
+			name:      "from2.Global",
+			from:      2,
+			global:    true,
+			wantCalls: "global",
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			calls := []string{}
+			record := func(call string) Action {
+				return func() error {
+					calls = append(calls, call)
+					return nil
+				}
+			}
+
+			obj := &testObject{state: test.from}
+			sm := New(obj)
+			if test.global {
+				sm.WithCommand(1, record("global"))
+			}
+			sm.From(0).On(1).Then(record("then0")).To(9).Add()
+			sm.From(1).On(1).Then(record("then1")).To(9).Add()
+			sm.From(2).On(1).To(9).Add()
+
+			if err := sm.Do(1); err != nil {
+				t.Fatalf("Unexpected error found: %s ", err.Error())
+			}
+
+			if expected, got := test.wantCalls, strings.Join(calls, ","); expected != got {
+				t.Errorf("Unexpected calls.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+
+			if got := obj.State(); got != 9 {
+				t.Errorf("Unexpected target state %v", got)
+			}
+		})
+	}
+}
+
+func Test_MultipleSourceTransitions(t *testing.T) {
+	tests := []struct {
+		name      string
+		from      State
+		cmdID     CommandID
+		to        State
+		wantError bool
+	}{
+		{
+			name:  "fromList.First",
+			from:  0,
+			cmdID: 1,
+			to:    3,
+		},
+		{
+			name:  "fromList.Second",
+			from:  1,
+			cmdID: 1,
+			to:    3,
+		},
+		{
+			name:      "fromList.Excepted",
+			from:      2,
+			cmdID:     1,
+			wantError: true,
+		},
+		{
+			name:  "fromAny",
+			from:  0,
+			cmdID: 9,
+			to:    5,
+		},
+		{
+			name:  "fromAny.StateSpecificFirst",
+			from:  1,
+			cmdID: 9,
+			to:    4,
+		},
+		{
+			name:      "fromAny.Excepted",
+			from:      2,
+			cmdID:     9,
+			wantError: true,
+		},
+		{
+			name:      "fromAny.Substate.Excepted",
+			from:      7,
+			cmdID:     9,
+			wantError: true,
+		},
+		{
+			name:      "fromAny.FinalState",
+			from:      6,
+			cmdID:     9,
+			wantError: true,
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			obj := &testObject{state: test.from}
+			sm := New(obj)
+			sm.Final(6).Substates(2, 7)
+			sm.From(0, 1, 2).Except(2).On(1).To(3).Add()
+			sm.From(1).On(9).To(4).Add()
+			sm.FromAny().Except(2).On(9).To(5).Add()
+
+			err := sm.Do(test.cmdID)
+			if test.wantError {
+				if err == nil {
+					t.Errorf("Expected error not found ")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error found: %s ", err.Error())
+			}
+
+			if expected, got := test.to, obj.State(); expected != got {
+				t.Errorf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package typed
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/cgxarrie-go/fsm"
+)
+
+// unknown identifies the states and commands not declared in the definition.
+const unknown = math.MaxUint32
+
+// Definition holds the commands and transitions of a state machine
+// independently of the objects it handles. It wraps a fsm.Definition, mapping
+// its states and commands to the ones of the machine. Once built the
+// definition cannot be modified, and can be shared by any number of machines.
+type Definition[S, C comparable, T any] struct {
+	def       *fsm.Definition
+	states    map[S]fsm.State
+	stateOf   []S
+	commands  map[C]fsm.CommandID
+	commandOf []C
+	getState  func(obj T) S
+	setState  func(obj T, s S)
+}
+
+func NewDefinition[S, C comparable, T any]() *Definition[S, C, T] {
+	return wrap[S, C, T](fsm.NewDefinition())
+}
+
+func wrap[S, C comparable, T any](def *fsm.Definition) *Definition[S, C, T] {
+	return &Definition[S, C, T]{
+		def:      def,
+		states:   map[S]fsm.State{},
+		commands: map[C]fsm.CommandID{},
+	}
+}
+
+// WithState sets the functions reading and setting the state of the
+// objects, needed when they do not implement Object.
+func (d *Definition[S, C, T]) WithState(get func(obj T) S,
+	set func(obj T, s S)) *Definition[S, C, T] {
+
+	d.getState = get
+	d.setState = set
+	return d
+}
+
+func (d *Definition[S, C, T]) WithCommand(id C,
+	action Action[T]) *Definition[S, C, T] {
+
+	d.def.WithCommand(d.command(id), action.object())
+	return d
+}
+
+func (d *Definition[S, C, T]) WithCommandContext(id C,
+	action ContextAction[T]) *Definition[S, C, T] {
+
+	d.def.WithCommandContext(d.command(id), action.context())
+	return d
+}
+
+// WithGuardsBeforeAction makes Do evaluate the transition conditions before
+// executing the command action, so the action only runs when a transition
+// can be taken.
+func (d *Definition[S, C, T]) WithGuardsBeforeAction() *Definition[S, C, T] {
+	d.def.WithGuardsBeforeAction()
+	return d
+}
+
+func (d *Definition[S, C, T]) WithTransactions() *Definition[S, C, T] {
+	d.def.WithTransactions()
+	return d
+}
+
+func (d *Definition[S, C, T]) WithMaxChain(n int) *Definition[S, C, T] {
+	d.def.WithMaxChain(n)
+	return d
+}
+
+func (d *Definition[S, C, T]) WithClock(c fsm.Clock) *Definition[S, C, T] {
+	d.def.WithClock(c)
+	return d
+}
+
+func (d *Definition[S, C, T]) WithVersionCheck() *Definition[S, C, T] {
+	d.def.WithVersionCheck()
+	return d
+}
+
+func (d *Definition[S, C, T]) From(states ...S) *TransitionBuilder[S, C, T] {
+	return d.builder(d.def.From(d.stateList(states)...))
+}
+
+func (d *Definition[S, C, T]) FromAny() *TransitionBuilder[S, C, T] {
+	return d.builder(d.def.FromAny())
+}
+
+func (d *Definition[S, C, T]) OnEnter(s S,
+	action Action[T]) *Definition[S, C, T] {
+
+	d.def.OnEnter(d.state(s), action.object())
+	return d
+}
+
+func (d *Definition[S, C, T]) OnExit(s S,
+	action Action[T]) *Definition[S, C, T] {
+
+	d.def.OnExit(d.state(s), action.object())
+	return d
+}
+
+func (d *Definition[S, C, T]) Substates(parent S,
+	children ...S) *Definition[S, C, T] {
+
+	d.def.Substates(d.state(parent), d.stateList(children)...)
+	return d
+}
+
+func (d *Definition[S, C, T]) Initial(parent S, child S) *Definition[S, C, T] {
+	d.def.Initial(d.state(parent), d.state(child))
+	return d
+}
+
+func (d *Definition[S, C, T]) Region(parent S, initial S,
+	states ...S) *Definition[S, C, T] {
+
+	d.def.Region(d.state(parent), d.state(initial), d.stateList(states)...)
+	return d
+}
+
+func (d *Definition[S, C, T]) History(parent S,
+	kind fsm.HistoryKind) *Definition[S, C, T] {
+
+	d.def.History(d.state(parent), kind)
+	return d
+}
+
+func (d *Definition[S, C, T]) Final(states ...S) *Definition[S, C, T] {
+	d.def.Final(d.stateList(states)...)
+	return d
+}
+
+func (d *Definition[S, C, T]) OnComplete(action Action[T]) *Definition[S, C, T] {
+	d.def.OnComplete(action.object())
+	return d
+}
+
+func (d *Definition[S, C, T]) Defer(s S, cmds ...C) *Definition[S, C, T] {
+	ids := make([]fsm.CommandID, len(cmds))
+	for i, cmd := range cmds {
+		ids[i] = d.command(cmd)
+	}
+
+	d.def.Defer(d.state(s), ids...)
+	return d
+}
+
+func (d *Definition[S, C, T]) Validate() error {
+	return d.def.Validate()
+}
+
+// Build validates the definition and makes it immutable, so it can be shared
+// by machines executing commands concurrently.
+func (d *Definition[S, C, T]) Build() (*Definition[S, C, T], error) {
+	if _, err := d.def.Build(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Bind returns a state machine handling the object with this definition,
+// building it first. It panics if the definition is not valid, or if the
+// object does not implement Object and no state functions are set.
+func (d *Definition[S, C, T]) Bind(obj T) StateMachine[S, C, T] {
+	o := d.object(obj)
+	return StateMachine[S, C, T]{sm: d.def.Bind(o), def: d, obj: obj}
+}
+
+// Definition returns the wrapped definition.
+func (d *Definition[S, C, T]) Definition() *fsm.Definition {
+	return d.def
+}
+
+func (d *Definition[S, C, T]) object(obj T) *object[S, C, T] {
+	if d.getState == nil {
+		if _, ok := any(obj).(Object[S]); !ok {
+			panic(fmt.Sprintf("typed: %T does not implement Object[%T]",
+				obj, *new(S)))
+		}
+	}
+	return &object[S, C, T]{obj: obj, def: d}
+}
+
+// state returns the identifier of the state, declaring it if needed.
+func (d *Definition[S, C, T]) state(s S) fsm.State {
+	if id, ok := d.states[s]; ok {
+		return id
+	}
+
+	id := fsm.State(len(d.stateOf))
+	d.states[s] = id
+	d.stateOf = append(d.stateOf, s)
+	return id
+}
+
+func (d *Definition[S, C, T]) stateList(states []S) []fsm.State {
+	ids := make([]fsm.State, len(states))
+	for i, s := range states {
+		ids[i] = d.state(s)
+	}
+	return ids
+}
+
+// command returns the identifier of the command, declaring it if needed.
+func (d *Definition[S, C, T]) command(cmd C) fsm.CommandID {
+	if id, ok := d.commands[cmd]; ok {
+		return id
+	}
+
+	id := fsm.CommandID(len(d.commandOf))
+	d.commands[cmd] = id
+	d.commandOf = append(d.commandOf, cmd)
+	return id
+}
+
+// stateID returns the identifier of a declared state, or unknown.
+func (d *Definition[S, C, T]) stateID(s S) fsm.State {
+	if id, ok := d.states[s]; ok {
+		return id
+	}
+	return unknown
+}
+
+func (d *Definition[S, C, T]) stateIDs(states []S) []fsm.State {
+	ids := make([]fsm.State, len(states))
+	for i, s := range states {
+		ids[i] = d.stateID(s)
+	}
+	return ids
+}
+
+func (d *Definition[S, C, T]) commandID(cmd C) fsm.CommandID {
+	if id, ok := d.commands[cmd]; ok {
+		return id
+	}
+	return unknown
+}
+
+func (d *Definition[S, C, T]) stateValue(id fsm.State) S {
+	if int(id) < len(d.stateOf) {
+		return d.stateOf[id]
+	}
+	return *new(S)
+}
+
+func (d *Definition[S, C, T]) stateValues(ids []fsm.State) []S {
+	states := make([]S, len(ids))
+	for i, id := range ids {
+		states[i] = d.stateValue(id)
+	}
+	return states
+}
+
+func (d *Definition[S, C, T]) commandValue(id fsm.CommandID) C {
+	if int(id) < len(d.commandOf) {
+		return d.commandOf[id]
+	}
+	return *new(C)
+}
+
+func (d *Definition[S, C, T]) events(events []fsm.Event) []Event[C] {
+	var values []Event[C]
+	for _, event := range events {
+		values = append(values, Event[C]{
+			Command: d.commandValue(event.Command),
+			Payload: event.Payload,
+		})
+	}
+	return values
+}
+
+func (d *Definition[S, C, T]) builder(
+	t *fsm.TransitionBuilder) *TransitionBuilder[S, C, T] {
+
+	return &TransitionBuilder[S, C, T]{def: d, t: t}
+}
@@ -0,0 +1,53 @@
+package typed
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cgxarrie-go/fsm"
+)
+
+// TransitionError is returned by Do when a command cannot be executed. It is
+// the typed counterpart of fsm.TransitionError, matching with errors.Is the
+// fsm sentinel error describing the kind of failure, and unwrapping to the
+// error that caused it, if any.
+type TransitionError[S, C comparable] struct {
+	From    S
+	Command C
+	Targets []S
+	Kind    error
+	Err     error
+}
+
+func (e *TransitionError[S, C]) Error() string {
+	msg := fmt.Sprintf("command %v from state %v: %v", e.Command, e.From,
+		e.Kind)
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	return msg
+}
+
+func (e *TransitionError[S, C]) Unwrap() error {
+	return e.Err
+}
+
+func (e *TransitionError[S, C]) Is(target error) bool {
+	return target == e.Kind
+}
+
+// error converts the transition errors of the wrapped machine.
+func (d *Definition[S, C, T]) error(err error) error {
+	var te *fsm.TransitionError
+	if !errors.As(err, &te) {
+		return err
+	}
+
+	return &TransitionError[S, C]{
+		From:    d.stateValue(te.From),
+		Command: d.commandValue(te.Command),
+		Targets: d.stateValues(te.Targets),
+		Kind:    te.Kind,
+		Err:     te.Err,
+	}
+}
@@ -0,0 +1,214 @@
+package typed
+
+import (
+	"time"
+
+	"github.com/cgxarrie-go/fsm"
+)
+
+// Object is implemented by objects handled by machines whose definitions
+// have no state functions.
+type Object[S comparable] interface {
+	SetState(S)
+	State() S
+}
+
+// ConfigurationObject is the typed counterpart of fsm.ConfigurationObject.
+type ConfigurationObject[S comparable] interface {
+	Configuration() []S
+	SetConfiguration([]S)
+}
+
+// HistoryObject is the typed counterpart of fsm.HistoryObject.
+type HistoryObject[S comparable] interface {
+	History() map[S][]S
+	SetHistory(map[S][]S)
+}
+
+// DeferredObject is the typed counterpart of fsm.DeferredObject.
+type DeferredObject[C comparable] interface {
+	Deferred() []Event[C]
+	SetDeferred([]Event[C])
+}
+
+// TimedObject is the typed counterpart of fsm.TimedObject.
+type TimedObject[S comparable] interface {
+	EnteredAt() map[S]time.Time
+	SetEnteredAt(map[S]time.Time)
+}
+
+type Event[C comparable] struct {
+	Command C
+	Payload interface{}
+}
+
+// object adapts the handled object to the wrapped machine. What the object
+// does not persist itself is kept by the adapter, as the wrapped machine does
+// for untyped objects.
+type object[S, C comparable, T any] struct {
+	obj T
+	def *Definition[S, C, T]
+
+	config   []fsm.State
+	history  map[fsm.State][]fsm.State
+	deferred []fsm.Event
+	entered  map[fsm.State]time.Time
+}
+
+func (o *object[S, C, T]) State() fsm.State {
+	if o.def.getState != nil {
+		return o.def.stateID(o.def.getState(o.obj))
+	}
+	return o.def.stateID(any(o.obj).(Object[S]).State())
+}
+
+func (o *object[S, C, T]) SetState(s fsm.State) {
+	if o.def.setState != nil {
+		o.def.setState(o.obj, o.def.stateValue(s))
+		return
+	}
+	any(o.obj).(Object[S]).SetState(o.def.stateValue(s))
+}
+
+func (o *object[S, C, T]) Configuration() []fsm.State {
+	if obj, ok := any(o.obj).(ConfigurationObject[S]); ok {
+		return o.def.stateIDs(obj.Configuration())
+	}
+	if len(o.config) > 0 && o.config[0] == o.State() {
+		return o.config
+	}
+	return nil
+}
+
+func (o *object[S, C, T]) SetConfiguration(config []fsm.State) {
+	if obj, ok := any(o.obj).(ConfigurationObject[S]); ok {
+		obj.SetConfiguration(o.def.stateValues(config))
+		return
+	}
+	o.config = config
+	o.SetState(config[0])
+}
+
+func (o *object[S, C, T]) History() map[fsm.State][]fsm.State {
+	obj, ok := any(o.obj).(HistoryObject[S])
+	if !ok {
+		return o.history
+	}
+
+	history := map[fsm.State][]fsm.State{}
+	for s, states := range obj.History() {
+		history[o.def.stateID(s)] = o.def.stateIDs(states)
+	}
+	return history
+}
+
+func (o *object[S, C, T]) SetHistory(history map[fsm.State][]fsm.State) {
+	obj, ok := any(o.obj).(HistoryObject[S])
+	if !ok {
+		o.history = history
+		return
+	}
+
+	values := map[S][]S{}
+	for s, states := range history {
+		values[o.def.stateValue(s)] = o.def.stateValues(states)
+	}
+	obj.SetHistory(values)
+}
+
+func (o *object[S, C, T]) Deferred() []fsm.Event {
+	obj, ok := any(o.obj).(DeferredObject[C])
+	if !ok {
+		return o.deferred
+	}
+
+	var events []fsm.Event
+	for _, event := range obj.Deferred() {
+		events = append(events, fsm.Event{
+			Command: o.def.commandID(event.Command),
+			Payload: event.Payload,
+		})
+	}
+	return events
+}
+
+func (o *object[S, C, T]) SetDeferred(events []fsm.Event) {
+	obj, ok := any(o.obj).(DeferredObject[C])
+	if !ok {
+		o.deferred = events
+		return
+	}
+	obj.SetDeferred(o.def.events(events))
+}
+
+func (o *object[S, C, T]) EnteredAt() map[fsm.State]time.Time {
+	obj, ok := any(o.obj).(TimedObject[S])
+	if !ok {
+		return o.entered
+	}
+
+	times := map[fsm.State]time.Time{}
+	for s, at := range obj.EnteredAt() {
+		times[o.def.stateID(s)] = at
+	}
+	return times
+}
+
+func (o *object[S, C, T]) SetEnteredAt(times map[fsm.State]time.Time) {
+	obj, ok := any(o.obj).(TimedObject[S])
+	if !ok {
+		o.entered = times
+		return
+	}
+
+	values := map[S]time.Time{}
+	for s, at := range times {
+		values[o.def.stateValue(s)] = at
+	}
+	obj.SetEnteredAt(values)
+}
+
+// Snapshot and Restore forward to objects implementing fsm.Snapshotter.
+func (o *object[S, C, T]) Snapshot() interface{} {
+	if s, ok := any(o.obj).(fsm.Snapshotter); ok {
+		return s.Snapshot()
+	}
+	return nil
+}
+
+func (o *object[S, C, T]) Restore(snapshot interface{}) {
+	if s, ok := any(o.obj).(fsm.Snapshotter); ok {
+		s.Restore(snapshot)
+	}
+}
+
+// versioned is implemented by objects keeping a version of their state, as
+// described by fsm.VersionedSMObject.
+type versioned interface {
+	Version() uint64
+	CompareAndSetVersion(old, new uint64) bool
+}
+
+// Version and CompareAndSetVersion forward to objects keeping a version.
+func (o *object[S, C, T]) Version() uint64 {
+	if v, ok := any(o.obj).(versioned); ok {
+		return v.Version()
+	}
+	return 0
+}
+
+func (o *object[S, C, T]) CompareAndSetVersion(old, new uint64) bool {
+	if v, ok := any(o.obj).(versioned); ok {
+		return v.CompareAndSetVersion(old, new)
+	}
+	return true
+}
+
+func (o *object[S, C, T]) value() T {
+	return o.obj
+}
+
+// holder is implemented by the adapters of the objects of type T.
+type holder[T any] interface {
+	value() T
+}
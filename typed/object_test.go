@@ -0,0 +1,129 @@
+package typed
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cgxarrie-go/fsm"
+)
+
+// persisted persists everything the machine keeps about it.
+type persisted struct {
+	door
+	config   []string
+	history  map[string][]string
+	deferred []Event[string]
+	entered  map[string]time.Time
+}
+
+func (p *persisted) Configuration() []string          { return p.config }
+func (p *persisted) SetConfiguration(config []string) { p.config = config }
+
+func (p *persisted) History() map[string][]string           { return p.history }
+func (p *persisted) SetHistory(history map[string][]string) { p.history = history }
+
+func (p *persisted) Deferred() []Event[string]          { return p.deferred }
+func (p *persisted) SetDeferred(events []Event[string]) { p.deferred = events }
+
+func (p *persisted) EnteredAt() map[string]time.Time         { return p.entered }
+func (p *persisted) SetEnteredAt(times map[string]time.Time) { p.entered = times }
+
+func Test_Regions(t *testing.T) {
+	tests := []struct {
+		name       string
+		obj        Object[string]
+		wantStored []string
+	}{
+		{
+			name: "plainObject",
+			obj:  &door{state: "off"},
+		},
+		{
+			name:       "configurationObject",
+			obj:        &persisted{door: door{state: "off"}},
+			wantStored: []string{"a2", "b2"},
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			def := NewDefinition[string, string, Object[string]]().
+				Region("p", "a1", "a2").
+				Region("p", "b1", "b2")
+			def.From("off").On("on").To("p").Add()
+			def.From("a1").On("x").To("a2").Add()
+			def.From("b1").On("y").To("b2").Add()
+
+			sm := def.Bind(test.obj)
+			for _, cmd := range []string{"on", "x", "y"} {
+				if err := sm.Do(cmd); err != nil {
+					t.Fatalf("Unexpected error %v", err)
+				}
+			}
+
+			if expected, got := []string{"a2", "b2"}, sm.Configuration(); !reflect.DeepEqual(expected, got) {
+				t.Errorf("Unexpected configuration.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+
+			if p, ok := test.obj.(*persisted); ok {
+				if expected, got := test.wantStored, p.config; !reflect.DeepEqual(expected, got) {
+					t.Errorf("Unexpected stored configuration.\n\tExpected: %v\n\tGot: %v",
+						expected, got)
+				}
+			}
+		})
+	}
+}
+
+func Test_PersistedObject(t *testing.T) {
+	clock := fsm.NewManualClock(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	def := NewDefinition[string, string, *persisted]().
+		WithClock(clock).
+		Substates("in", "closed", "opened").
+		Initial("in", "closed").
+		History("in", fsm.ShallowHistory).
+		Defer("out", "open")
+	def.From("closed").On("open").To("opened").Add()
+	def.From("in").On("leave").To("out").Add()
+	def.From("out").On("enter").ToHistory("in").Add()
+	def.From("out").On("expire").After(time.Hour).To("gone").Add()
+
+	obj := &persisted{door: door{state: "closed"}}
+	sm := def.Bind(obj)
+	for _, cmd := range []string{"open", "leave", "open"} {
+		if err := sm.Do(cmd); err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+	}
+
+	if expected, got := map[string][]string{"in": {"opened"}}, obj.history; !reflect.DeepEqual(expected, got) {
+		t.Errorf("Unexpected stored history.\n\tExpected: %v\n\tGot: %v",
+			expected, got)
+	}
+
+	if expected, got := []Event[string]{{Command: "open"}}, obj.deferred; !reflect.DeepEqual(expected, got) {
+		t.Errorf("Unexpected stored deferred commands.\n\tExpected: %v\n\tGot: %v",
+			expected, got)
+	}
+
+	if _, ok := obj.entered["out"]; !ok {
+		t.Errorf("Unexpected stored entry times %v", obj.entered)
+	}
+
+	// A machine bound to the reloaded object continues where it was.
+	reloaded := def.Bind(obj)
+	clock.Advance(time.Hour)
+	if err := reloaded.FireTimeouts(context.Background()); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if expected, got := []string{"gone"}, reloaded.Configuration(); !reflect.DeepEqual(expected, got) {
+		t.Errorf("Unexpected configuration.\n\tExpected: %v\n\tGot: %v",
+			expected, got)
+	}
+}
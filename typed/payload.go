@@ -0,0 +1,33 @@
+package typed
+
+import (
+	"context"
+
+	"github.com/cgxarrie-go/fsm"
+)
+
+// PayloadAction adapts an action receiving a typed payload, as
+// fsm.PayloadAction does.
+func PayloadAction[T, P any](
+	action func(ctx context.Context, obj T, payload P) error) ContextAction[T] {
+
+	return func(ctx context.Context, obj T) error {
+		return fsm.PayloadAction(
+			func(ctx context.Context, _ fsm.SMObject, payload P) error {
+				return action(ctx, obj, payload)
+			})(ctx, nil)
+	}
+}
+
+// PayloadCondition adapts a condition receiving a typed payload, as
+// fsm.PayloadCondition does.
+func PayloadCondition[T, P any](
+	cond func(ctx context.Context, obj T, payload P) bool) ContextCondition[T] {
+
+	return func(ctx context.Context, obj T) bool {
+		return fsm.PayloadCondition(
+			func(ctx context.Context, _ fsm.SMObject, payload P) bool {
+				return cond(ctx, obj, payload)
+			})(ctx, nil)
+	}
+}
@@ -0,0 +1,41 @@
+package typed
+
+// CanDo reports whether the command can be executed from the current state
+// of the object. Conditions are evaluated against the current object, without
+// executing the command action.
+func (fsm StateMachine[S, C, T]) CanDo(cmdID C) bool {
+	return fsm.sm.CanDo(fsm.def.commandID(cmdID))
+}
+
+// AvailableCommands returns, in the order they were declared, the commands
+// that can be executed from the current state of the object.
+func (fsm StateMachine[S, C, T]) AvailableCommands() []C {
+	cmds := []C{}
+	for _, cmdID := range fsm.sm.AvailableCommands() {
+		cmds = append(cmds, fsm.def.commandValue(cmdID))
+	}
+	return cmds
+}
+
+// PossibleTargets returns, in evaluation order, the states reachable from the
+// current state of the object with the command whose conditions are currently
+// met.
+func (fsm StateMachine[S, C, T]) PossibleTargets(cmdID C) []S {
+	return fsm.def.stateValues(fsm.sm.PossibleTargets(fsm.def.commandID(cmdID)))
+}
+
+// Configuration returns the active states of the object, one per active
+// orthogonal region.
+func (fsm StateMachine[S, C, T]) Configuration() []S {
+	return fsm.def.stateValues(fsm.sm.Configuration())
+}
+
+// IsFinal reports whether the state of the object is final.
+func (fsm StateMachine[S, C, T]) IsFinal() bool {
+	return fsm.sm.IsFinal()
+}
+
+// Done reports whether all the active states of the object are final.
+func (fsm StateMachine[S, C, T]) Done() bool {
+	return fsm.sm.Done()
+}
@@ -0,0 +1,178 @@
+// Package typed provides a generic variant of the fsm state machine, where
+// states, commands and the handled object keep their own types. Machines
+// wrap a fsm.StateMachine, so they share all its features, and objects
+// persist their active states, history, deferred commands and entry times
+// implementing the typed counterparts of the fsm interfaces.
+package typed
+
+import (
+	"context"
+	"time"
+
+	"github.com/cgxarrie-go/fsm"
+)
+
+type Action[T any] func(obj T) error
+type Condition[T any] func(obj T) bool
+type ContextAction[T any] func(ctx context.Context, obj T) error
+type ContextCondition[T any] func(ctx context.Context, obj T) bool
+
+type StateMachine[S, C comparable, T any] struct {
+	sm  fsm.StateMachine
+	def *Definition[S, C, T]
+	obj T
+}
+
+// New returns a machine handling the object with its own definition, which
+// can be modified until the machine executes its first command.
+func New[S, C comparable, T any](element T) StateMachine[S, C, T] {
+	def := NewDefinition[S, C, T]()
+	sm := fsm.New(&object[S, C, T]{obj: element, def: def})
+	def.def = sm.Definition()
+	return StateMachine[S, C, T]{sm: sm, def: def, obj: element}
+}
+
+// Definition returns the definition of the machine.
+func (fsm *StateMachine[S, C, T]) Definition() *Definition[S, C, T] {
+	return fsm.def
+}
+
+func (fsm *StateMachine[S, C, T]) WithCommand(id C,
+	action Action[T]) *StateMachine[S, C, T] {
+
+	fsm.def.WithCommand(id, action)
+	return fsm
+}
+
+func (fsm *StateMachine[S, C, T]) WithCommandContext(id C,
+	action ContextAction[T]) *StateMachine[S, C, T] {
+
+	fsm.def.WithCommandContext(id, action)
+	return fsm
+}
+
+// WithGuardsBeforeAction makes Do evaluate the transition conditions before
+// executing the command action, so the action only runs when a transition
+// can be taken.
+func (fsm *StateMachine[S, C, T]) WithGuardsBeforeAction() *StateMachine[S, C, T] {
+	fsm.def.WithGuardsBeforeAction()
+	return fsm
+}
+
+func (fsm *StateMachine[S, C, T]) From(states ...S) *TransitionBuilder[S, C, T] {
+	return fsm.def.From(states...)
+}
+
+func (fsm *StateMachine[S, C, T]) FromAny() *TransitionBuilder[S, C, T] {
+	return fsm.def.FromAny()
+}
+
+func (fsm *StateMachine[S, C, T]) OnEnter(s S,
+	action Action[T]) *StateMachine[S, C, T] {
+
+	fsm.def.OnEnter(s, action)
+	return fsm
+}
+
+func (fsm *StateMachine[S, C, T]) OnExit(s S,
+	action Action[T]) *StateMachine[S, C, T] {
+
+	fsm.def.OnExit(s, action)
+	return fsm
+}
+
+// Object returns the object handled by the machine.
+func (fsm StateMachine[S, C, T]) Object() T {
+	return fsm.obj
+}
+
+// Machine returns the wrapped machine, to be used with actors, schedulers
+// and managers.
+func (fsm StateMachine[S, C, T]) Machine() fsm.StateMachine {
+	return fsm.sm
+}
+
+func (fsm StateMachine[S, C, T]) Validate() error {
+	return fsm.sm.Validate()
+}
+
+func (fsm StateMachine[S, C, T]) Build() error {
+	return fsm.sm.Build()
+}
+
+func (fsm StateMachine[S, C, T]) Do(cmdID C) error {
+	return fsm.DoContext(context.Background(), cmdID)
+}
+
+// DoContext executes the command honouring the context. Errors are returned
+// as *TransitionError.
+func (fsm StateMachine[S, C, T]) DoContext(ctx context.Context, cmdID C) error {
+	return fsm.def.error(fsm.sm.DoContext(ctx, fsm.def.commandID(cmdID)))
+}
+
+func (fsm StateMachine[S, C, T]) DoWith(cmdID C, payload interface{}) error {
+	return fsm.DoWithContext(context.Background(), cmdID, payload)
+}
+
+func (fsm StateMachine[S, C, T]) DoWithContext(ctx context.Context, cmdID C,
+	payload interface{}) error {
+
+	return fsm.def.error(fsm.sm.DoWithContext(ctx, fsm.def.commandID(cmdID),
+		payload))
+}
+
+func (fsm StateMachine[S, C, T]) Raise(cmdID C) {
+	fsm.sm.Raise(fsm.def.commandID(cmdID))
+}
+
+func (fsm StateMachine[S, C, T]) RaiseWith(cmdID C, payload interface{}) {
+	fsm.sm.RaiseWith(fsm.def.commandID(cmdID), payload)
+}
+
+// Deferred returns the commands stored until they can be executed, in the
+// order they were received.
+func (fsm StateMachine[S, C, T]) Deferred() []Event[C] {
+	return fsm.def.events(fsm.sm.Deferred())
+}
+
+func (fsm StateMachine[S, C, T]) NextTimeout() (time.Time, bool) {
+	return fsm.sm.NextTimeout()
+}
+
+func (fsm StateMachine[S, C, T]) FireTimeouts(ctx context.Context) error {
+	return fsm.def.error(fsm.sm.FireTimeouts(ctx))
+}
+
+func (a Action[T]) object() fsm.ObjectAction {
+	if a == nil {
+		return nil
+	}
+	return func(obj fsm.SMObject) error { return a(obj.(holder[T]).value()) }
+}
+
+func (a ContextAction[T]) context() fsm.ContextAction {
+	if a == nil {
+		return nil
+	}
+	return func(ctx context.Context, obj fsm.SMObject) error {
+		return a(ctx, obj.(holder[T]).value())
+	}
+}
+
+func (c Condition[T]) context() fsm.ContextCondition {
+	if c == nil {
+		return nil
+	}
+	return func(_ context.Context, obj fsm.SMObject) bool {
+		return c(obj.(holder[T]).value())
+	}
+}
+
+func (c ContextCondition[T]) context() fsm.ContextCondition {
+	if c == nil {
+		return nil
+	}
+	return func(ctx context.Context, obj fsm.SMObject) bool {
+		return c(ctx, obj.(holder[T]).value())
+	}
+}
@@ -0,0 +1,380 @@
+package typed
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/cgxarrie-go/fsm"
+)
+
+type door struct {
+	state  string
+	opened int
+}
+
+func (d *door) SetState(s string) {
+	d.state = s
+}
+
+func (d *door) State() string {
+	return d.state
+}
+
+func (d *door) Open() error {
+	d.opened++
+	return nil
+}
+
+// record has no state methods, its state is handled by the definition.
+type record struct {
+	status string
+	log    []string
+}
+
+func doorDefinition() *Definition[string, string, *door] {
+	def := NewDefinition[string, string, *door]().
+		WithCommand("open", (*door).Open)
+	def.From("closed").
+		On("open").To("opened").Add().
+		On("lock").To("locked").Add()
+	def.From("opened").On("close").To("closed").Add()
+	def.From("locked").On("unlock").To("closed").Add()
+	return def
+}
+
+func Test_Do(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		cmd     string
+		to      string
+		opened  int
+		wantErr error
+	}{
+		{
+			name:   "open",
+			from:   "closed",
+			cmd:    "open",
+			to:     "opened",
+			opened: 1,
+		},
+		{
+			name: "lock",
+			from: "closed",
+			cmd:  "lock",
+			to:   "locked",
+		},
+		{
+			name:    "notAllowed",
+			from:    "locked",
+			cmd:     "open",
+			to:      "locked",
+			wantErr: fsm.ErrNotAllowed,
+		},
+		{
+			name:    "unknownCommand",
+			from:    "closed",
+			cmd:     "kick",
+			to:      "closed",
+			wantErr: fsm.ErrUnknownCommand,
+		},
+	}
+
+	def := doorDefinition()
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			obj := &door{state: test.from}
+			sm := def.Bind(obj)
+
+			err := sm.Do(test.cmd)
+
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("Unexpected error.\n\tExpected: %v\n\tGot: %v",
+					test.wantErr, err)
+			}
+
+			if expected, got := test.to, obj.State(); expected != got {
+				t.Errorf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+
+			if expected, got := test.opened, obj.opened; expected != got {
+				t.Errorf("Unexpected action calls.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+		})
+	}
+}
+
+func Test_New(t *testing.T) {
+	obj := &door{state: "closed"}
+	sm := New[string, string](obj)
+	sm.WithCommand("open", (*door).Open)
+	sm.From("closed").On("open").To("opened").Add()
+	sm.From("opened").On("close").To("closed").Add()
+
+	if err := sm.Do("open"); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if expected, got := "opened", obj.State(); expected != got {
+		t.Errorf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+			expected, got)
+	}
+
+	if expected, got := 1, obj.opened; expected != got {
+		t.Errorf("Unexpected action calls.\n\tExpected: %v\n\tGot: %v",
+			expected, got)
+	}
+
+	if sm.Object() != obj {
+		t.Errorf("Unexpected object %v", sm.Object())
+	}
+}
+
+func Test_SharedDefinition(t *testing.T) {
+	def, err := doorDefinition().Build()
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	objs := make([]*door, 8)
+	var wg sync.WaitGroup
+	for i := range objs {
+		objs[i] = &door{state: "closed"}
+		wg.Add(1)
+		go func(obj *door) {
+			defer wg.Done()
+			sm := def.Bind(obj)
+			_ = sm.Do("open")
+			_ = sm.Do("close")
+		}(objs[i])
+	}
+	wg.Wait()
+
+	for _, obj := range objs {
+		if obj.State() != "closed" || obj.opened != 1 {
+			t.Errorf("Unexpected object %+v", obj)
+		}
+	}
+}
+
+func Test_StateFunctions(t *testing.T) {
+	def := NewDefinition[string, int, *record]().
+		WithState(
+			func(r *record) string { return r.status },
+			func(r *record, s string) { r.status = s }).
+		OnEnter("archived", func(r *record) error {
+			r.log = append(r.log, "archived")
+			return nil
+		})
+	def.From("active").On(1).To("archived").Add()
+
+	obj := &record{status: "active"}
+	sm := def.Bind(obj)
+
+	if err := sm.Do(1); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if expected, got := "archived", obj.status; expected != got {
+		t.Errorf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+			expected, got)
+	}
+
+	if expected, got := []string{"archived"}, obj.log; !reflect.DeepEqual(expected, got) {
+		t.Errorf("Unexpected hooks.\n\tExpected: %v\n\tGot: %v",
+			expected, got)
+	}
+}
+
+func Test_BindWithoutState(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected panic binding an object without state")
+		}
+	}()
+
+	def := NewDefinition[string, int, *record]()
+	def.From("active").On(1).To("archived").Add()
+	def.Bind(&record{})
+}
+
+func Test_TransitionErrors(t *testing.T) {
+	errAction := errors.New("action")
+
+	def := NewDefinition[string, string, *door]().
+		WithCommand("open", func(*door) error { return errAction })
+	def.From("closed").
+		On("open").To("opened").Add().
+		On("lock").If(func(*door) bool { return false }).To("locked").Add()
+
+	tests := []struct {
+		name        string
+		cmd         string
+		wantKind    error
+		wantCause   error
+		wantTargets []string
+	}{
+		{
+			name:        "actionFailed",
+			cmd:         "open",
+			wantKind:    fsm.ErrActionFailed,
+			wantCause:   errAction,
+			wantTargets: []string{"opened"},
+		},
+		{
+			name:        "guardRejected",
+			cmd:         "lock",
+			wantKind:    fsm.ErrGuardRejected,
+			wantTargets: []string{"locked"},
+		},
+	}
+
+	for _, test := range tests {
+
+		t.Run(test.name, func(t *testing.T) {
+			sm := def.Bind(&door{state: "closed"})
+
+			err := sm.Do(test.cmd)
+
+			if !errors.Is(err, test.wantKind) {
+				t.Errorf("Unexpected error kind.\n\tExpected: %v\n\tGot: %v",
+					test.wantKind, err)
+			}
+
+			if test.wantCause != nil && !errors.Is(err, test.wantCause) {
+				t.Errorf("Unexpected error cause.\n\tExpected: %v\n\tGot: %v",
+					test.wantCause, err)
+			}
+
+			var trErr *TransitionError[string, string]
+			if !errors.As(err, &trErr) {
+				t.Fatalf("Expected TransitionError, got %T", err)
+			}
+
+			if trErr.From != "closed" || trErr.Command != test.cmd {
+				t.Errorf("Unexpected error transition %v/%v", trErr.From,
+					trErr.Command)
+			}
+
+			if expected, got := test.wantTargets, trErr.Targets; !reflect.DeepEqual(expected, got) {
+				t.Errorf("Unexpected error targets.\n\tExpected: %v\n\tGot: %v",
+					expected, got)
+			}
+		})
+	}
+}
+
+func Test_Queries(t *testing.T) {
+	def := doorDefinition()
+	sm := def.Bind(&door{state: "closed"})
+
+	if !sm.CanDo("lock") || sm.CanDo("unlock") {
+		t.Errorf("Unexpected CanDo results")
+	}
+
+	if expected, got := []string{"open", "lock"}, sm.AvailableCommands(); !reflect.DeepEqual(expected, got) {
+		t.Errorf("Unexpected available commands.\n\tExpected: %v\n\tGot: %v",
+			expected, got)
+	}
+
+	if expected, got := []string{"locked"}, sm.PossibleTargets("lock"); !reflect.DeepEqual(expected, got) {
+		t.Errorf("Unexpected possible targets.\n\tExpected: %v\n\tGot: %v",
+			expected, got)
+	}
+
+	if expected, got := []string{"closed"}, sm.Configuration(); !reflect.DeepEqual(expected, got) {
+		t.Errorf("Unexpected configuration.\n\tExpected: %v\n\tGot: %v",
+			expected, got)
+	}
+}
+
+func Test_Payload(t *testing.T) {
+	def := NewDefinition[string, string, *record]().
+		WithState(
+			func(r *record) string { return r.status },
+			func(r *record, s string) { r.status = s }).
+		WithCommandContext("note", PayloadAction(
+			func(_ context.Context, r *record, note string) error {
+				r.log = append(r.log, note)
+				return nil
+			}))
+	def.From("active").
+		On("note").IfContext(PayloadCondition(
+		func(_ context.Context, _ *record, note string) bool {
+			return note != ""
+		})).Internal().Add()
+
+	obj := &record{status: "active"}
+	sm := def.Bind(obj)
+
+	if err := sm.DoWith("note", "first"); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if err := sm.DoWith("note", 1); !errors.Is(err, fsm.ErrPayloadType) {
+		t.Errorf("Unexpected error.\n\tExpected: %v\n\tGot: %v",
+			fsm.ErrPayloadType, err)
+	}
+
+	if expected, got := []string{"first"}, obj.log; !reflect.DeepEqual(expected, got) {
+		t.Errorf("Unexpected notes.\n\tExpected: %v\n\tGot: %v",
+			expected, got)
+	}
+}
+
+func Test_HierarchyAndDeferral(t *testing.T) {
+	def := NewDefinition[string, string, *door]().
+		Substates("in", "closed", "opened").
+		Initial("in", "closed").
+		Defer("closed", "close")
+	def.From("closed").On("open").To("opened").Add()
+	def.From("opened").On("close").To("closed").Add()
+	def.From("in").On("leave").To("out").Add()
+	def.From("out").On("enter").To("in").Add()
+
+	obj := &door{state: "out"}
+	sm := def.Bind(obj)
+
+	if err := sm.Do("enter"); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if expected, got := "closed", obj.State(); expected != got {
+		t.Errorf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+			expected, got)
+	}
+
+	if err := sm.Do("close"); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if expected, got := []Event[string]{{Command: "close"}}, sm.Deferred(); !reflect.DeepEqual(expected, got) {
+		t.Errorf("Unexpected deferred commands.\n\tExpected: %v\n\tGot: %v",
+			expected, got)
+	}
+
+	if err := sm.Do("open"); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if expected, got := "closed", obj.State(); expected != got {
+		t.Errorf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+			expected, got)
+	}
+
+	if err := sm.Do("leave"); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if expected, got := "out", obj.State(); expected != got {
+		t.Errorf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+			expected, got)
+	}
+}
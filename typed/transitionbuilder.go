@@ -0,0 +1,122 @@
+package typed
+
+import (
+	"time"
+
+	"github.com/cgxarrie-go/fsm"
+)
+
+type TransitionBuilder[S, C comparable, T any] struct {
+	def *Definition[S, C, T]
+	t   *fsm.TransitionBuilder
+}
+
+// Except excludes states from the source states of the transition.
+func (t *TransitionBuilder[S, C, T]) Except(states ...S) *TransitionBuilder[S, C, T] {
+	t.t.Except(t.def.stateList(states)...)
+	return t
+}
+
+func (t *TransitionBuilder[S, C, T]) To(s S) *TransitionBuilder[S, C, T] {
+	t.t.To(t.def.state(s))
+	return t
+}
+
+// ToHistory targets the history pseudo-state of the state.
+func (t *TransitionBuilder[S, C, T]) ToHistory(s S) *TransitionBuilder[S, C, T] {
+	t.t.ToHistory(t.def.state(s))
+	return t
+}
+
+func (t *TransitionBuilder[S, C, T]) On(cmd C) *TransitionBuilder[S, C, T] {
+	t.t.On(t.def.command(cmd))
+	return t
+}
+
+func (t *TransitionBuilder[S, C, T]) If(cond Condition[T]) *TransitionBuilder[S, C, T] {
+	t.t.IfContext(cond.context())
+	return t
+}
+
+func (t *TransitionBuilder[S, C, T]) IfContext(
+	cond ContextCondition[T]) *TransitionBuilder[S, C, T] {
+
+	t.t.IfContext(cond.context())
+	return t
+}
+
+// Then sets the action executed only when this transition is taken.
+func (t *TransitionBuilder[S, C, T]) Then(action Action[T]) *TransitionBuilder[S, C, T] {
+	t.t.ThenObject(action.object())
+	return t
+}
+
+func (t *TransitionBuilder[S, C, T]) ThenContext(
+	action ContextAction[T]) *TransitionBuilder[S, C, T] {
+
+	t.t.ThenContext(action.context())
+	return t
+}
+
+// Internal makes the transition internal, executing its actions without
+// leaving the source state.
+func (t *TransitionBuilder[S, C, T]) Internal() *TransitionBuilder[S, C, T] {
+	t.t.Internal()
+	return t
+}
+
+// After makes the transition timed, so it can only be taken once the
+// duration has elapsed since the object entered the source state.
+func (t *TransitionBuilder[S, C, T]) After(d time.Duration) *TransitionBuilder[S, C, T] {
+	t.t.After(d)
+	return t
+}
+
+// Priority sets the evaluation priority of the transition among those
+// declared for the same state and command. Higher values are evaluated first.
+func (t *TransitionBuilder[S, C, T]) Priority(p int) *TransitionBuilder[S, C, T] {
+	t.t.Priority(p)
+	return t
+}
+
+// Else marks the transition as fallback, evaluated only when no other
+// transition for the same state and command can be taken.
+func (t *TransitionBuilder[S, C, T]) Else() *TransitionBuilder[S, C, T] {
+	t.t.Else()
+	return t
+}
+
+func (t *TransitionBuilder[S, C, T]) Add() *TransitionBuilder[S, C, T] {
+	return t.def.builder(t.t.Add())
+}
+
+// ToChoice targets a choice pseudo-state, whose branches are added with
+// When and Otherwise.
+func (t *TransitionBuilder[S, C, T]) ToChoice() *ChoiceBuilder[S, C, T] {
+	return &ChoiceBuilder[S, C, T]{def: t.def, c: t.t.ToChoice()}
+}
+
+type ChoiceBuilder[S, C comparable, T any] struct {
+	def *Definition[S, C, T]
+	c   *fsm.ChoiceBuilder
+}
+
+// When adds a branch to the choice, taken when its condition is met and no
+// previous branch was taken.
+func (c *ChoiceBuilder[S, C, T]) When(cond Condition[T], s S) *ChoiceBuilder[S, C, T] {
+	c.c.WhenContext(cond.context(), c.def.state(s))
+	return c
+}
+
+func (c *ChoiceBuilder[S, C, T]) WhenContext(cond ContextCondition[T],
+	s S) *ChoiceBuilder[S, C, T] {
+
+	c.c.WhenContext(cond.context(), c.def.state(s))
+	return c
+}
+
+// Otherwise adds the branch taken when no other branch is taken, and adds
+// the choice to the state machine.
+func (c *ChoiceBuilder[S, C, T]) Otherwise(s S) *TransitionBuilder[S, C, T] {
+	return c.def.builder(c.c.Otherwise(c.def.state(s)))
+}
@@ -0,0 +1,63 @@
+package fsm
+
+import "fmt"
+
+// VersionedSMObject is implemented by objects keeping a version of their
+// state, so changes made to them concurrently can be detected. The version
+// is the one the object had when it was loaded, and CompareAndSetVersion
+// sets it to new only if the stored version is still old, usually checking
+// it in the store the object was loaded from.
+type VersionedSMObject interface {
+	SMObject
+	Version() uint64
+	CompareAndSetVersion(old, new uint64) bool
+}
+
+// ConflictError is the cause of the ErrConflict transition errors. It holds
+// the version the object had when the command started.
+type ConflictError struct {
+	Version uint64
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("version %d changed concurrently", e.Version)
+}
+
+// WithVersionCheck makes Do increment, once the transition of objects
+// implementing VersionedSMObject has completed, the version they had when
+// the command started. Do fails with ErrConflict, restoring the states of the
+// object, when that version has been changed concurrently, and leaves the
+// version unchanged when the transition fails.
+func (d *Definition) WithVersionCheck() *Definition {
+	d.mustNotBeSealed()
+	d.versioned = true
+	return d
+}
+
+func (fsm *StateMachine) WithVersionCheck() *StateMachine {
+	fsm.def.WithVersionCheck()
+	return fsm
+}
+
+// version returns the version of the object, when it is checked.
+func (fsm StateMachine) version() (uint64, bool) {
+	if !fsm.def.versioned {
+		return 0, false
+	}
+
+	obj, ok := fsm.smObject.(VersionedSMObject)
+	if !ok {
+		return 0, false
+	}
+	return obj.Version(), true
+}
+
+// bump increments the version of the object, unless it is no longer the
+// version it had when the command started.
+func (fsm StateMachine) bump(version uint64) error {
+	obj := fsm.smObject.(VersionedSMObject)
+	if !obj.CompareAndSetVersion(version, version+1) {
+		return &ConflictError{Version: version}
+	}
+	return nil
+}
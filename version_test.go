@@ -0,0 +1,155 @@
+package fsm
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// versionStore is the storage shared by the objects loaded from it.
+type versionStore struct {
+	mu      sync.Mutex
+	state   State
+	version uint64
+}
+
+func (s *versionStore) load() *versionedObject {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &versionedObject{testObject: testObject{state: s.state},
+		store: s, version: s.version}
+}
+
+type versionedObject struct {
+	testObject
+	store   *versionStore
+	version uint64
+}
+
+func (o *versionedObject) Version() uint64 {
+	return o.version
+}
+
+func (o *versionedObject) CompareAndSetVersion(old, new uint64) bool {
+	o.store.mu.Lock()
+	defer o.store.mu.Unlock()
+
+	if o.store.version != old {
+		return false
+	}
+	o.store.version = new
+	o.version = new
+	return true
+}
+
+func Test_VersionCheck(t *testing.T) {
+	const (
+		draft State = iota
+		confirmed
+	)
+
+	const (
+		confirm CommandID = iota
+		edit
+	)
+
+	tests := []struct {
+		name        string
+		checked     bool
+		cmdID       CommandID
+		concurrent  bool
+		entryFails  bool
+		to          State
+		wantVersion uint64
+		wantError   error
+	}{
+		{
+			name:        "incremented",
+			checked:     true,
+			cmdID:       confirm,
+			to:          confirmed,
+			wantVersion: 4,
+		},
+		{
+			name:        "conflict",
+			checked:     true,
+			cmdID:       confirm,
+			concurrent:  true,
+			to:          draft,
+			wantVersion: 4,
+			wantError:   ErrConflict,
+		},
+		{
+			name:        "entryFailed",
+			checked:     true,
+			cmdID:       confirm,
+			entryFails:  true,
+			to:          confirmed,
+			wantVersion: 3,
+			wantError:   ErrActionFailed,
+		},
+		{
+			name:        "internal",
+			checked:     true,
+			cmdID:       edit,
+			to:          draft,
+			wantVersion: 3,
+		},
+		{
+			name:        "notChecked",
+			cmdID:       confirm,
+			concurrent:  true,
+			to:          confirmed,
+			wantVersion: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := &versionStore{version: 3}
+			def := NewDefinition()
+			if test.checked {
+				def.WithVersionCheck()
+			}
+			def.From(draft).
+				On(confirm).To(confirmed).Add().
+				On(edit).Internal().Add()
+			def.OnEnter(confirmed, func(SMObject) error {
+				if test.entryFails {
+					return errors.New("entry")
+				}
+				return nil
+			})
+
+			obj := store.load()
+			if test.concurrent {
+				other := store.load()
+				if err := def.Bind(other).Do(confirm); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+
+			err := def.Bind(obj).Do(test.cmdID)
+			if !errors.Is(err, test.wantError) {
+				t.Fatalf("Unexpected error.\n\tExpected: %v\n\tGot: %v",
+					test.wantError, err)
+			}
+
+			var conflict *ConflictError
+			if test.wantError == ErrConflict && !errors.As(err, &conflict) {
+				t.Errorf("Unexpected error type.\n\tExpected: %T\n\tGot: %T",
+					conflict, errors.Unwrap(err))
+			}
+
+			if obj.State() != test.to {
+				t.Errorf("Unexpected target state.\n\tExpected: %v\n\tGot: %v",
+					test.to, obj.State())
+			}
+
+			if store.version != test.wantVersion {
+				t.Errorf("Unexpected version.\n\tExpected: %v\n\tGot: %v",
+					test.wantVersion, store.version)
+			}
+		})
+	}
+}